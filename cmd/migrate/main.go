@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"go-image-api/database/migrator"
+	"go-image-api/internal/config"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up          Apply every pending migration
+  down [n]    Revert the latest n applied migrations (default as 1)
+  status      List migrations and whether they are applied`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	viperConfig := config.NewViper()
+	log := config.NewLogger(viperConfig)
+	db := config.NewDatabase(viperConfig, log)
+
+	switch os.Args[1] {
+	case "up":
+		if err := migrator.Up(db); err != nil {
+			log.Fatalf("Failed to migrate the database : %+v", err)
+		}
+		fmt.Println("Database is up to date")
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			var err error
+			if steps, err = strconv.Atoi(os.Args[2]); err != nil || steps < 1 {
+				log.Fatalf("Number of migrations to revert should be a positive number : %s", os.Args[2])
+			}
+		}
+		if err := migrator.Down(db, steps); err != nil {
+			log.Fatalf("Failed to revert the database : %+v", err)
+		}
+		fmt.Println("Migrations are reverted")
+	case "status":
+		statuses, err := migrator.Status(db)
+		if err != nil {
+			log.Fatalf("Failed to get migration status : %+v", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d  %-40s  %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
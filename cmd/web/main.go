@@ -11,7 +11,7 @@ func main() {
 	app := config.NewFiber(viperConfig)
 	db := config.NewDatabase(viperConfig, log)
 	validate := config.NewValidate(viperConfig)
-	cld := config.NewCloudinary(viperConfig, log)
+	store := config.NewStorage(viperConfig, log)
 
 	configBootstrap := &config.ConfigBootstrap{
 		ViperConfig: viperConfig,
@@ -19,7 +19,7 @@ func main() {
 		App:         app,
 		DB:          db,
 		Validate:    validate,
-		Cloudinary:  cld,
+		Storage:     store,
 	}
 	config.Bootstrap(configBootstrap)
 
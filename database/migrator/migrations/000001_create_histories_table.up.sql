@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS histories (
+    id BIGSERIAL PRIMARY KEY,
+    timestamp TIMESTAMPTZ,
+    type TEXT,
+    extension_before TEXT,
+    extension_after TEXT,
+    size_before_in_mb NUMERIC,
+    size_after_in_mb NUMERIC,
+    height_before_in_px BIGINT,
+    height_after_in_px BIGINT,
+    width_before_in_px BIGINT,
+    width_after_in_px BIGINT,
+    image_link_before TEXT,
+    image_link_after TEXT
+);
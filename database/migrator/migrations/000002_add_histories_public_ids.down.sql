@@ -0,0 +1,3 @@
+ALTER TABLE histories
+    DROP COLUMN IF EXISTS image_public_id_before,
+    DROP COLUMN IF EXISTS image_public_id_after;
@@ -0,0 +1,3 @@
+ALTER TABLE histories
+    ADD COLUMN IF NOT EXISTS image_public_id_before TEXT,
+    ADD COLUMN IF NOT EXISTS image_public_id_after TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE histories
+    ADD COLUMN IF NOT EXISTS parameters JSONB;
@@ -0,0 +1,5 @@
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS callback_url;
+
+DROP TABLE IF EXISTS webhook_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
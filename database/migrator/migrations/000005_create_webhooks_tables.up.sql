@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    payload JSONB,
+    status TEXT NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_delivery_id UUID NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    number BIGINT NOT NULL,
+    status_code BIGINT,
+    error TEXT,
+    duration_in_ms BIGINT,
+    timestamp TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_webhook_delivery_id ON webhook_attempts (webhook_delivery_id);
+
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS callback_url TEXT;
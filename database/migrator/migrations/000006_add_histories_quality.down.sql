@@ -0,0 +1,2 @@
+ALTER TABLE histories
+    DROP COLUMN IF EXISTS quality;
@@ -0,0 +1,3 @@
+ALTER TABLE histories
+    DROP COLUMN IF EXISTS ssim,
+    DROP COLUMN IF EXISTS psnr;
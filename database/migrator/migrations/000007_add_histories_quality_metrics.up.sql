@@ -0,0 +1,3 @@
+ALTER TABLE histories
+    ADD COLUMN IF NOT EXISTS ssim DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS psnr DOUBLE PRECISION;
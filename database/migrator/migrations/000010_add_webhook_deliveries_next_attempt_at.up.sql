@@ -0,0 +1,6 @@
+ALTER TABLE webhook_deliveries
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+
+UPDATE webhook_deliveries SET next_attempt_at = created_at WHERE next_attempt_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
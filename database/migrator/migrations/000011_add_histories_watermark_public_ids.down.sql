@@ -0,0 +1,8 @@
+ALTER TABLE histories
+    ADD COLUMN IF NOT EXISTS watermark_public_id TEXT;
+
+UPDATE histories SET watermark_public_id = watermark_public_ids ->> 0
+WHERE jsonb_typeof(watermark_public_ids) = 'array';
+
+ALTER TABLE histories
+    DROP COLUMN IF EXISTS watermark_public_ids;
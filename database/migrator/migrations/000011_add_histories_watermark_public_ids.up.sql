@@ -0,0 +1,8 @@
+ALTER TABLE histories
+    ADD COLUMN IF NOT EXISTS watermark_public_ids JSONB;
+
+UPDATE histories SET watermark_public_ids = jsonb_build_array(watermark_public_id)
+WHERE watermark_public_id IS NOT NULL AND watermark_public_id <> '';
+
+ALTER TABLE histories
+    DROP COLUMN IF EXISTS watermark_public_id;
@@ -1,23 +1,218 @@
 package migrator
 
 import (
-	"go-image-api/internal/entity"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&entity.History{}); err != nil {
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Arbitrary key for the postgres advisory lock, so concurrent instances do not migrate at the same time
+const migrationLockKey = 7_302_918_465
+
+type Migration struct {
+	Version int
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Applies every pending migration in version order
+func Up(db *gorm.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		applied, err := lock(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := tx.Exec(migration.UpSQL).Error; err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s : %w", migration.Version, migration.Name, err)
+			}
+			if err := tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func Drop(db *gorm.DB) error {
-	if err := db.Migrator().DropTable(&entity.History{}); err != nil {
+// Reverts the given number of latest applied migrations
+func Down(db *gorm.DB, steps int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		applied, err := lock(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := tx.Exec(migration.DownSQL).Error; err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s : %w", migration.Version, migration.Name, err)
+			}
+			if err := tx.Delete(&schemaMigration{Version: migration.Version}).Error; err != nil {
+				return err
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Lists every known migration along with the time it was applied
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = db.Transaction(func(tx *gorm.DB) error {
+		applied, err := lock(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Takes the migration lock for the rest of the transaction and returns applied versions
+func lock(tx *gorm.DB) (map[int]time.Time, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	return applied, nil
+}
+
+// Reads embedded migrations, named as <version>_<name>.up.sql and <version>_<name>.down.sql
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrationByVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name : %s", fileName)
+		}
+
+		versionStr, name, found := strings.Cut(strings.TrimSuffix(fileName, "."+direction+".sql"), "_")
+		version, err := strconv.Atoi(versionStr)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid migration file name : %s", fileName)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrationByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			migrationByVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationByVersion))
+	for _, migration := range migrationByVersion {
+		if migration.UpSQL == "" || migration.DownSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s should have both up and down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
 }
@@ -1,13 +1,14 @@
 package config
 
 import (
+	"context"
 	"go-image-api/database/migrator"
 	"go-image-api/internal/delivery/http/controller"
 	"go-image-api/internal/delivery/http/route"
 	"go-image-api/internal/repository"
+	"go-image-api/internal/storage"
 	"go-image-api/internal/usecase"
 
-	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -21,7 +22,7 @@ type ConfigBootstrap struct {
 	App         *fiber.App
 	DB          *gorm.DB
 	Validate    *validator.Validate
-	Cloudinary  *cloudinary.Cloudinary
+	Storage     storage.Storage
 }
 
 func Bootstrap(configBootstrap *ConfigBootstrap) {
@@ -34,7 +35,7 @@ func Bootstrap(configBootstrap *ConfigBootstrap) {
 		configBootstrap.DB,
 		configBootstrap.Validate,
 		configBootstrap.Log,
-		configBootstrap.Cloudinary,
+		configBootstrap.Storage,
 		repositorySetup,
 	)
 
@@ -45,16 +46,16 @@ func Bootstrap(configBootstrap *ConfigBootstrap) {
 	routeConfig := route.RouteConfig{
 		App:             configBootstrap.App,
 		ControllerSetup: controllerSetup,
+		Storage:         configBootstrap.Storage,
 	}
 	routeConfig.Setup()
 
-	// Drop the database
-	if err := migrator.Drop(configBootstrap.DB); err != nil {
-		configBootstrap.Log.Fatalf("Failed to drop the database: %+v", err)
-	}
-
-	// Migrate the database
-	if err := migrator.Migrate(configBootstrap.DB); err != nil {
+	// Apply pending migrations, reverting is only done through cmd/migrate
+	if err := migrator.Up(configBootstrap.DB); err != nil {
 		configBootstrap.Log.Fatalf("Failed to migrate the database: %+v", err)
 	}
+
+	// Start the workers of asynchronous jobs and webhook deliveries, after their tables are migrated
+	useCaseSetup.JobUseCase.StartWorkers(context.Background())
+	useCaseSetup.WebhookUseCase.StartWorkers(context.Background())
 }
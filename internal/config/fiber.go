@@ -1,10 +1,8 @@
 package config
 
 import (
-	"fmt"
-	"go-image-api/internal/model"
+	"go-image-api/internal/helper"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
 )
@@ -26,32 +24,8 @@ func NewFiber(viperConfig *viper.Viper) *fiber.App {
 
 func customErrorHandler() func(*fiber.Ctx, error) error {
 	return func(c *fiber.Ctx, err error) error {
-		var response model.ErrorResponse
-
 		if err != nil {
-			if errConv, ok := err.(validator.ValidationErrors); ok {
-				response.Code = fiber.StatusBadRequest
-
-				for _, errItem := range errConv {
-					switch errItem.Tag() {
-					case "required":
-						response.Messages = append(response.Messages, fmt.Sprintf("%s is required", errItem.Field()))
-					case "min":
-						response.Messages = append(response.Messages, fmt.Sprintf("%s is should more than %d",
-							errItem.Tag(), errItem.Value()))
-					case "max":
-						response.Messages = append(response.Messages, fmt.Sprintf("%s is should me less than %d",
-							errItem.Tag(), errItem.Value()))
-					}
-				}
-			} else if errConv, ok := err.(*fiber.Error); ok {
-				response.Code = errConv.Code
-				response.Messages = []string{errConv.Message}
-			} else {
-				response.Code = fiber.StatusInternalServerError
-				response.Messages = []string{"Internal server error"}
-			}
-
+			response := helper.NewErrorResponse(err)
 			return c.Status(response.Code).JSON(response)
 		}
 
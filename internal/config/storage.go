@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"go-image-api/internal/storage"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func NewStorage(viperConfig *viper.Viper, log *logrus.Logger) storage.Storage {
+	// If storage driver is not configured, set defaults to cloudinary
+	driver := viperConfig.GetString("STORAGE_DRIVER")
+	switch driver {
+	case "", "cloudinary":
+		return storage.NewCloudinaryStorage(NewCloudinary(viperConfig, log))
+	case "local":
+		return newLocalStorage(viperConfig, log)
+	case "s3":
+		return newS3Storage(viperConfig, log)
+	}
+
+	log.Fatalf("Unknown storage driver : %s", driver)
+	return nil
+}
+
+func newLocalStorage(viperConfig *viper.Viper, log *logrus.Logger) *storage.LocalStorage {
+	// If root directory is not configured, set defaults to ./storage
+	root := viperConfig.GetString("STORAGE_LOCAL_ROOT")
+	if root == "" {
+		root = "./storage"
+	}
+
+	// If base url is not configured, set defaults to the local app address
+	baseURL := viperConfig.GetString("STORAGE_LOCAL_BASE_URL")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://localhost:%d", viperConfig.GetInt("APP_PORT"))
+	}
+
+	localStorage, err := storage.NewLocalStorage(root, "/files", baseURL)
+	if err != nil {
+		log.Fatalf("Failed to creating local storage : %+v", err)
+	}
+
+	return localStorage
+}
+
+func newS3Storage(viperConfig *viper.Viper, log *logrus.Logger) *storage.S3Storage {
+	bucketLookup := minio.BucketLookupAuto
+	if viperConfig.GetBool("STORAGE_S3_PATH_STYLE") {
+		bucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(viperConfig.GetString("STORAGE_S3_ENDPOINT"), &minio.Options{
+		Creds: credentials.NewStaticV4(
+			viperConfig.GetString("STORAGE_S3_ACCESS_KEY"),
+			viperConfig.GetString("STORAGE_S3_SECRET_KEY"),
+			"",
+		),
+		Secure:       viperConfig.GetBool("STORAGE_S3_USE_SSL"),
+		Region:       viperConfig.GetString("STORAGE_S3_REGION"),
+		BucketLookup: bucketLookup,
+	})
+	if err != nil {
+		log.Fatalf("Failed to creating s3 client : %+v", err)
+	}
+
+	// Create the bucket if it does not exist yet
+	bucket := viperConfig.GetString("STORAGE_S3_BUCKET")
+	exists, err := client.BucketExists(context.Background(), bucket)
+	if err != nil {
+		log.Fatalf("Failed to check s3 bucket : %+v", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(context.Background(), bucket, minio.MakeBucketOptions{
+			Region: viperConfig.GetString("STORAGE_S3_REGION"),
+		}); err != nil {
+			log.Fatalf("Failed to create s3 bucket : %+v", err)
+		}
+	}
+
+	// If presigned url expiry is not configured, set defaults to 1 hour
+	urlExpiry := viperConfig.GetInt("STORAGE_S3_URL_EXPIRY_IN_SECONDS")
+	if urlExpiry < 1 {
+		urlExpiry = 3600
+	}
+
+	return storage.NewS3Storage(client, bucket, viperConfig.GetString("STORAGE_S3_PUBLIC_URL"),
+		time.Duration(urlExpiry)*time.Second)
+}
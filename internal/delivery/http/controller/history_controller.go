@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"go-image-api/internal/model"
+	"go-image-api/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type HistoryController struct {
+	Log            *logrus.Logger
+	HistoryUseCase *usecase.HistoryUseCase
+}
+
+func NewHistoryController(log *logrus.Logger, historyUseCase *usecase.HistoryUseCase) *HistoryController {
+	return &HistoryController{
+		Log:            log,
+		HistoryUseCase: historyUseCase,
+	}
+}
+
+func (ct *HistoryController) List(c *fiber.Ctx) error {
+	request := &model.SearchHistoryRequest{
+		Page:            c.QueryInt("page", 1),
+		Size:            c.QueryInt("size", 10),
+		Type:            c.Query("type"),
+		ExtensionBefore: c.Query("extension_before"),
+		ExtensionAfter:  c.Query("extension_after"),
+		SortBy:          c.Query("sort_by", "timestamp"),
+		SortOrder:       c.Query("sort_order", "desc"),
+	}
+
+	// Parse optional timestamp range, timestamps are expected in RFC3339
+	var err error
+	if request.TimestampFrom, err = parseQueryTime(c, "timestamp_from"); err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'timestamp_from' should be in RFC3339 format")
+	}
+	if request.TimestampTo, err = parseQueryTime(c, "timestamp_to"); err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'timestamp_to' should be in RFC3339 format")
+	}
+
+	// Parse optional size range
+	if request.MinSizeInMB, err = parseQueryFloat(c, "min_size_in_mb"); err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'min_size_in_mb' should be a number")
+	}
+	if request.MaxSizeInMB, err = parseQueryFloat(c, "max_size_in_mb"); err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'max_size_in_mb' should be a number")
+	}
+
+	// Send request to usecase
+	response, err := ct.HistoryUseCase.Search(c.UserContext(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *HistoryController) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'id' should be a number")
+	}
+
+	// Send request to usecase
+	request := &model.GetHistoryRequest{ID: id}
+	response, err := ct.HistoryUseCase.Get(c.UserContext(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *HistoryController) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		ct.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'id' should be a number")
+	}
+
+	// Send request to usecase
+	request := &model.DeleteHistoryRequest{ID: id}
+	if err := ct.HistoryUseCase.Delete(c.UserContext(), request); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func parseQueryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+func parseQueryFloat(c *fiber.Ctx, key string) (*float64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
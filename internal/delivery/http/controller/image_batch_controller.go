@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"fmt"
+	"go-image-api/internal/model"
+	"mime/multipart"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Maximum number of images accepted by a single batch request
+const batchMaxFiles = 20
+
+func (ct *ImageController) BatchConvertPNGToJPEG(c *fiber.Ctx) error {
+	files, err := ct.batchFiles(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Send request to usecase, every file is validated separately so one invalid file does not fail the batch
+	requests := make([]*model.ImageRequest, len(files))
+	for i, file := range files {
+		requests[i] = &model.ImageRequest{
+			AlphaParams: model.AlphaParams{
+				BackgroundColor: c.FormValue("background_color"),
+				TrimTransparent: c.FormValue("trim_transparent") == "true",
+			},
+			JPEGParams:      jpegParams(c),
+			OrientParams:    model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+			ImageFileHeader: file,
+		}
+	}
+	response := ct.ImageUseCase.BatchConvertPNGToJPEG(c.UserContext(), requests)
+	ct.notify(c, callbackURL, response, nil)
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) BatchResize(c *fiber.Ctx) error {
+	files, err := ct.batchFiles(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Send request to usecase, every file is validated separately so one invalid file does not fail the batch
+	widthReq, _ := strconv.Atoi(c.FormValue("width_in_pixels"))
+	heightReq, _ := strconv.Atoi(c.FormValue("height_in_pixels"))
+	requests := make([]*model.ImageResizeRequest, len(files))
+	for i, file := range files {
+		requests[i] = &model.ImageResizeRequest{
+			ResizeParams: model.ResizeParams{
+				WidthInPixels:   widthReq,
+				HeightInPixels:  heightReq,
+				Mode:            c.FormValue("mode"),
+				NoUpscale:       c.FormValue("no_upscale") == "true",
+				Interpolation:   c.FormValue("interpolation"),
+				Gravity:         c.FormValue("gravity"),
+				BackgroundColor: c.FormValue("background_color"),
+				OrientParams:    model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+			},
+			ImageFileHeader: file,
+		}
+	}
+	response := ct.ImageUseCase.BatchResizeImage(c.UserContext(), requests)
+	ct.notify(c, callbackURL, response, nil)
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) BatchCompress(c *fiber.Ctx) error {
+	files, err := ct.batchFiles(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Empty 'compress_quality' field is left to the encoder default, which keeps png lossless
+	qualityReq, _ := strconv.Atoi(c.FormValue("compress_quality"))
+	pngLevelReq, _ := strconv.Atoi(c.FormValue("png_compression_level"))
+	pngColorsReq, _ := strconv.Atoi(c.FormValue("png_colors"))
+	targetSizeReq, _ := strconv.Atoi(c.FormValue("target_size_kb"))
+	minSSIMReq, _ := strconv.ParseFloat(c.FormValue("min_ssim"), 64)
+
+	// Send request to usecase, every file is validated separately so one invalid file does not fail the batch
+	requests := make([]*model.ImageCompressRequest, len(files))
+	for i, file := range files {
+		requests[i] = &model.ImageCompressRequest{
+			CompressParams: model.CompressParams{
+				CompressQuality:     qualityReq,
+				PNGCompressionLevel: pngLevelReq,
+				PNGColors:           pngColorsReq,
+				PNGDither:           c.FormValue("png_dither") == "true",
+				TargetSizeInKB:      targetSizeReq,
+				AllowDownscale:      c.FormValue("allow_downscale") == "true",
+				MinSSIM:             minSSIMReq,
+				JPEGParams:          jpegParams(c),
+				OrientParams:        model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+			},
+			ImageFileHeader: file,
+		}
+	}
+	response := ct.ImageUseCase.BatchCompressImage(c.UserContext(), requests)
+	ct.notify(c, callbackURL, response, nil)
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// Returns every uploaded 'image' file
+func (ct *ImageController) batchFiles(c *fiber.Ctx) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	files := form.File["image"]
+	if len(files) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	if len(files) > batchMaxFiles {
+		ct.Log.Warnf("Validation error : %d files uploaded in a batch", len(files))
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("'image' should be at most %d files", batchMaxFiles))
+	}
+
+	return files, nil
+}
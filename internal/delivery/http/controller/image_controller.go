@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"encoding/json"
+	"go-image-api/internal/helper"
 	"go-image-api/internal/model"
 	"go-image-api/internal/usecase"
+	"mime/multipart"
 	"slices"
 	"strconv"
 
@@ -11,17 +14,83 @@ import (
 )
 
 type ImageController struct {
-	Log          *logrus.Logger
-	ImageUseCase *usecase.ImageUseCase
+	Log            *logrus.Logger
+	ImageUseCase   *usecase.ImageUseCase
+	JobUseCase     *usecase.JobUseCase
+	WebhookUseCase *usecase.WebhookUseCase
 }
 
-func NewImageController(log *logrus.Logger, imageUseCase *usecase.ImageUseCase) *ImageController {
+func NewImageController(log *logrus.Logger, imageUseCase *usecase.ImageUseCase, jobUseCase *usecase.JobUseCase,
+	webhookUseCase *usecase.WebhookUseCase) *ImageController {
 	return &ImageController{
-		Log:          log,
-		ImageUseCase: imageUseCase,
+		Log:            log,
+		ImageUseCase:   imageUseCase,
+		JobUseCase:     jobUseCase,
+		WebhookUseCase: webhookUseCase,
 	}
 }
 
+func (ct *ImageController) Convert(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg, image/webp, image/bmp, image/tiff, image/gif header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+		"image/webp",
+		"image/bmp",
+		"image/tiff",
+		"image/gif",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpeg, image/webp, image/bmp, image/tiff, or image/gif")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpeg, webp, bmp, tiff, or gif")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	request := &model.ImageConvertRequest{
+		ConvertParams: model.ConvertParams{
+			TargetFormat: c.FormValue("target_format"),
+			AlphaParams: model.AlphaParams{
+				BackgroundColor: c.FormValue("background_color"),
+				TrimTransparent: c.FormValue("trim_transparent") == "true",
+			},
+			JPEGParams:   jpegParams(c),
+			OrientParams: model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "convert_image", &request.ConvertParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.ConvertImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// Kept for compatibility, same as Convert with png input and jpeg target format
 func (ct *ImageController) ConvertPNGToJPEG(c *fiber.Ctx) error {
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -36,15 +105,39 @@ func (ct *ImageController) ConvertPNGToJPEG(c *fiber.Ctx) error {
 	}
 	file := form.File["image"][0]
 
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
 	// Validate header, only accepts image/png header
 	if file.Header["Content-Type"][0] != "image/png" {
 		ct.Log.Warn("Validation error : file header is not image/png")
 		return fiber.NewError(fiber.StatusBadRequest, "file should be in png")
 	}
 
-	// Send request to usecase
-	request := &model.ImageRequest{ImageFileHeader: file}
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	request := &model.ImageRequest{
+		AlphaParams: model.AlphaParams{
+			BackgroundColor: c.FormValue("background_color"),
+			TrimTransparent: c.FormValue("trim_transparent") == "true",
+		},
+		JPEGParams:      jpegParams(c),
+		OrientParams:    model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		params := &model.ConvertParams{
+			TargetFormat: "jpeg",
+			AlphaParams:  request.AlphaParams,
+			JPEGParams:   request.JPEGParams,
+			OrientParams: request.OrientParams,
+		}
+		return ct.enqueue(c, "convert_png_jpeg", params, file, callbackURL)
+	}
 	response, err := ct.ImageUseCase.ConvertPNGToJPEG(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
 	if err != nil {
 		return err
 	}
@@ -66,6 +159,12 @@ func (ct *ImageController) Resize(c *fiber.Ctx) error {
 	}
 	file := form.File["image"][0]
 
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
 	// Validate header, only accepts image/png, image/jpg, image/jpeg header
 	extConstraint := []string{
 		"image/png",
@@ -77,15 +176,27 @@ func (ct *ImageController) Resize(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
 	}
 
-	// Send request to usecase
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
 	widthReq, _ := strconv.Atoi(c.FormValue("width_in_pixels"))
 	heightReq, _ := strconv.Atoi(c.FormValue("height_in_pixels"))
 	request := &model.ImageResizeRequest{
-		WidthInPixels:   widthReq,
-		HeightInPixels:  heightReq,
+		ResizeParams: model.ResizeParams{
+			WidthInPixels:   widthReq,
+			HeightInPixels:  heightReq,
+			Mode:            c.FormValue("mode"),
+			NoUpscale:       c.FormValue("no_upscale") == "true",
+			Interpolation:   c.FormValue("interpolation"),
+			Gravity:         c.FormValue("gravity"),
+			BackgroundColor: c.FormValue("background_color"),
+			OrientParams:    model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
 		ImageFileHeader: file,
 	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "resize_image", &request.ResizeParams, file, callbackURL)
+	}
 	response, err := ct.ImageUseCase.ResizeImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
 	if err != nil {
 		return err
 	}
@@ -107,6 +218,78 @@ func (ct *ImageController) Compress(c *fiber.Ctx) error {
 	}
 	file := form.File["image"][0]
 
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg, image/webp header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+		"image/webp",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, image/jpeg, or image/webp")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, jpeg, or webp")
+	}
+
+	// Empty 'compress_quality' field is left to the encoder default, which keeps png lossless
+	qualityReq, _ := strconv.Atoi(c.FormValue("compress_quality"))
+	pngLevelReq, _ := strconv.Atoi(c.FormValue("png_compression_level"))
+	pngColorsReq, _ := strconv.Atoi(c.FormValue("png_colors"))
+	targetSizeReq, _ := strconv.Atoi(c.FormValue("target_size_kb"))
+	minSSIMReq, _ := strconv.ParseFloat(c.FormValue("min_ssim"), 64)
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	request := &model.ImageCompressRequest{
+		CompressParams: model.CompressParams{
+			CompressQuality:     qualityReq,
+			PNGCompressionLevel: pngLevelReq,
+			PNGColors:           pngColorsReq,
+			PNGDither:           c.FormValue("png_dither") == "true",
+			TargetSizeInKB:      targetSizeReq,
+			AllowDownscale:      c.FormValue("allow_downscale") == "true",
+			MinSSIM:             minSSIMReq,
+			JPEGParams:          jpegParams(c),
+			OrientParams:        model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "compress_image", &request.CompressParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.CompressImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Crop(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
 	// Validate header, only accepts image/png, image/jpg, image/jpeg header
 	extConstraint := []string{
 		"image/png",
@@ -118,21 +301,462 @@ func (ct *ImageController) Compress(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
 	}
 
-	// If 'compress_quality' field is empty, set default as 70
-	qualityReq, _ := strconv.Atoi(c.FormValue("compress_quality"))
-	if qualityReq < 1 {
-		qualityReq = 70
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	xReq, _ := strconv.Atoi(c.FormValue("x"))
+	yReq, _ := strconv.Atoi(c.FormValue("y"))
+	widthReq, _ := strconv.Atoi(c.FormValue("width_in_pixels"))
+	heightReq, _ := strconv.Atoi(c.FormValue("height_in_pixels"))
+	xPercentReq, _ := strconv.ParseFloat(c.FormValue("x_percent"), 64)
+	yPercentReq, _ := strconv.ParseFloat(c.FormValue("y_percent"), 64)
+	widthPercentReq, _ := strconv.ParseFloat(c.FormValue("width_percent"), 64)
+	heightPercentReq, _ := strconv.ParseFloat(c.FormValue("height_percent"), 64)
+	request := &model.ImageCropRequest{
+		CropParams: model.CropParams{
+			Mode:           c.FormValue("mode"),
+			X:              xReq,
+			Y:              yReq,
+			WidthInPixels:  widthReq,
+			HeightInPixels: heightReq,
+			XPercent:       xPercentReq,
+			YPercent:       yPercentReq,
+			WidthPercent:   widthPercentReq,
+			HeightPercent:  heightPercentReq,
+			Gravity:        c.FormValue("gravity"),
+			OrientParams:   model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "crop_image", &request.CropParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.CropImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
 	}
 
-	// Send request to usecase
-	request := &model.ImageCompressRequest{
-		CompressQuality: qualityReq,
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Rotate(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, or image/jpeg")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	angleReq, _ := strconv.ParseFloat(c.FormValue("angle_in_degrees"), 64)
+	request := &model.ImageRotateRequest{
+		RotateParams: model.RotateParams{
+			AngleInDegrees:  angleReq,
+			Flip:            c.FormValue("flip"),
+			BackgroundColor: c.FormValue("background_color"),
+			OrientParams:    model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
 		ImageFileHeader: file,
 	}
-	response, err := ct.ImageUseCase.CompressImage(c.UserContext(), request)
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "rotate_image", &request.RotateParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.RotateImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
 	if err != nil {
 		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+func (ct *ImageController) Watermark(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Logo is optional, text watermark is used when it is not uploaded
+	var watermarkFile *multipart.FileHeader
+	if len(form.File["watermark"]) > 0 {
+		watermarkFile = form.File["watermark"][0]
+		if watermarkFile.Header["Content-Type"][0] != "image/png" {
+			ct.Log.Warn("Validation error : watermark header is not image/png")
+			return fiber.NewError(fiber.StatusBadRequest, "watermark should be in png")
+		}
+	}
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, or image/jpeg")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	marginReq, _ := strconv.Atoi(c.FormValue("margin_in_pixels"))
+	opacityReq, _ := strconv.ParseFloat(c.FormValue("opacity"), 64)
+	scaleReq, _ := strconv.ParseFloat(c.FormValue("scale"), 64)
+	request := &model.ImageWatermarkRequest{
+		WatermarkParams: model.WatermarkParams{
+			Text:           c.FormValue("text"),
+			TextColor:      c.FormValue("text_color"),
+			Gravity:        c.FormValue("gravity"),
+			MarginInPixels: marginReq,
+			Opacity:        opacityReq,
+			Scale:          scaleReq,
+			Tiled:          c.FormValue("tiled") == "true",
+			OrientParams:   model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader:     file,
+		WatermarkFileHeader: watermarkFile,
+	}
+	if c.FormValue("async") == "true" {
+		// The logo is queued within the parameters
+		if err := ct.ImageUseCase.ReadWatermark(request); err != nil {
+			return err
+		}
+		return ct.enqueue(c, "watermark_image", &request.WatermarkParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.WatermarkImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Filter(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, or image/jpeg")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	brightnessReq, _ := strconv.ParseFloat(c.FormValue("brightness"), 64)
+	contrastReq, _ := strconv.ParseFloat(c.FormValue("contrast"), 64)
+	gammaReq, _ := strconv.ParseFloat(c.FormValue("gamma"), 64)
+	blurReq, _ := strconv.ParseFloat(c.FormValue("blur"), 64)
+	sharpenReq, _ := strconv.ParseFloat(c.FormValue("sharpen"), 64)
+	request := &model.ImageFilterRequest{
+		FilterParams: model.FilterParams{
+			Grayscale:    c.FormValue("grayscale") == "true",
+			Brightness:   brightnessReq,
+			Contrast:     contrastReq,
+			Gamma:        gammaReq,
+			Blur:         blurReq,
+			Sharpen:      sharpenReq,
+			OrientParams: model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "filter_image", &request.FilterParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.FilterImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Enhance(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, or image/jpeg")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	clipLimitReq, _ := strconv.ParseFloat(c.FormValue("clip_limit"), 64)
+	tileGridSizeReq, _ := strconv.Atoi(c.FormValue("tile_grid_size"))
+	request := &model.ImageEnhanceRequest{
+		EnhanceParams: model.EnhanceParams{
+			Method:       c.FormValue("method"),
+			ColorSpace:   c.FormValue("color_space"),
+			ClipLimit:    clipLimitReq,
+			TileGridSize: tileGridSizeReq,
+			OrientParams: model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "enhance_image", &request.EnhanceParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.EnhanceImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Denoise(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpg, or image/jpeg")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	strengthReq, _ := strconv.ParseFloat(c.FormValue("strength"), 64)
+	colorStrengthReq, _ := strconv.ParseFloat(c.FormValue("color_strength"), 64)
+	templateWindowSizeReq, _ := strconv.Atoi(c.FormValue("template_window_size"))
+	searchWindowSizeReq, _ := strconv.Atoi(c.FormValue("search_window_size"))
+	request := &model.ImageDenoiseRequest{
+		DenoiseParams: model.DenoiseParams{
+			Strength:           strengthReq,
+			ColorStrength:      colorStrengthReq,
+			TemplateWindowSize: templateWindowSizeReq,
+			SearchWindowSize:   searchWindowSizeReq,
+			OrientParams:       model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "denoise_image", &request.DenoiseParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.DenoiseImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (ct *ImageController) Transform(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		ct.Log.Warnf("Failed to parse request multipart/form : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	// Get first uploaded files (if multiple files are uploaded) and only process the first file
+	if len(form.File["image"]) == 0 {
+		ct.Log.Warn("Validation error : 'image' field is required")
+		return fiber.NewError(fiber.StatusBadRequest, "'image' is required")
+	}
+	file := form.File["image"][0]
+
+	// Validate 'callback_url' field
+	callbackURL, err := ct.callbackURL(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate header, only accepts image/png, image/jpg, image/jpeg, image/webp, image/bmp, image/tiff, image/gif header
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+		"image/webp",
+		"image/bmp",
+		"image/tiff",
+		"image/gif",
+	}
+	if !slices.Contains(extConstraint, file.Header["Content-Type"][0]) {
+		ct.Log.Warn("Validation error : file header is not image/png, image/jpeg, image/webp, image/bmp, image/tiff, or image/gif")
+		return fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpeg, webp, bmp, tiff, or gif")
+	}
+
+	// Parse 'operations' field, an ordered JSON array of operations
+	var operations []model.TransformOperation
+	if err := json.Unmarshal([]byte(c.FormValue("operations")), &operations); err != nil {
+		ct.Log.Warnf("Validation error : 'operations' is not a valid JSON array : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'operations' should be a JSON array of operations")
+	}
+
+	// Send request to usecase, or queue it as a job when asked to be processed asynchronously
+	request := &model.ImageTransformRequest{
+		TransformParams: model.TransformParams{
+			Operations:   operations,
+			OrientParams: model.OrientParams{AutoOrient: c.FormValue("auto_orient") == "true"},
+		},
+		ImageFileHeader: file,
+	}
+	if c.FormValue("async") == "true" {
+		return ct.enqueue(c, "transform_image", &request.TransformParams, file, callbackURL)
+	}
+	response, err := ct.ImageUseCase.TransformImage(c.UserContext(), request)
+	ct.notify(c, callbackURL, response, err)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// Queues the operation as a job, responds with the job to be polled
+func (ct *ImageController) enqueue(c *fiber.Ctx, jobType string, params any, file *multipart.FileHeader, callbackURL string) error {
+	request := &model.EnqueueJobRequest{
+		Type:            jobType,
+		Params:          params,
+		CallbackURL:     callbackURL,
+		ImageFileHeader: file,
+	}
+	response, err := ct.JobUseCase.Enqueue(c.UserContext(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// Reads and validates the 'callback_url' field, the outcome is posted into it once processed
+func (ct *ImageController) callbackURL(c *fiber.Ctx) (string, error) {
+	callbackURL := c.FormValue("callback_url")
+	if err := ct.WebhookUseCase.CheckCallbackURL(callbackURL); err != nil {
+		return "", err
+	}
+
+	return callbackURL, nil
+}
+
+// Posts the outcome of the operation into the callback url, if any
+func (ct *ImageController) notify(c *fiber.Ctx, callbackURL string, response any, err error) {
+	if callbackURL == "" {
+		return
+	}
+
+	request := &model.WebhookRequest{CallbackURL: callbackURL, Payload: response}
+	if err != nil {
+		request.Payload = helper.NewErrorResponse(err)
+	}
+	if err := ct.WebhookUseCase.Notify(c.UserContext(), request); err != nil {
+		ct.Log.Warnf("Failed to notify callback url : %+v", err)
+	}
+}
+
+// Reads the advanced jpeg encoder options shared by the compress and convert endpoints
+func jpegParams(c *fiber.Ctx) model.JPEGParams {
+	lumaQualityReq, _ := strconv.Atoi(c.FormValue("luma_quality"))
+	chromaQualityReq, _ := strconv.Atoi(c.FormValue("chroma_quality"))
+	restartIntervalReq, _ := strconv.Atoi(c.FormValue("restart_interval"))
+
+	return model.JPEGParams{
+		Progressive:       c.FormValue("progressive") == "true",
+		Optimize:          c.FormValue("optimize") == "true",
+		LumaQuality:       lumaQualityReq,
+		ChromaQuality:     chromaQualityReq,
+		ChromaSubsampling: c.FormValue("chroma_subsampling"),
+		RestartInterval:   restartIntervalReq,
+	}
+}
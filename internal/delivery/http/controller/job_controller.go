@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"go-image-api/internal/model"
+	"go-image-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type JobController struct {
+	Log        *logrus.Logger
+	JobUseCase *usecase.JobUseCase
+}
+
+func NewJobController(log *logrus.Logger, jobUseCase *usecase.JobUseCase) *JobController {
+	return &JobController{
+		Log:        log,
+		JobUseCase: jobUseCase,
+	}
+}
+
+func (ct *JobController) Get(c *fiber.Ctx) error {
+	// Send request to usecase
+	request := &model.GetJobRequest{ID: c.Params("id")}
+	response, err := ct.JobUseCase.Get(c.UserContext(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -7,11 +7,15 @@ import (
 )
 
 type ControllerSetup struct {
-	ImageController *ImageController
+	ImageController   *ImageController
+	HistoryController *HistoryController
+	JobController     *JobController
 }
 
 func Setup(log *logrus.Logger, useCaseSetup *usecase.UseCaseSetup) *ControllerSetup {
 	return &ControllerSetup{
-		ImageController: NewImageController(log, useCaseSetup.ImageUseCase),
+		ImageController:   NewImageController(log, useCaseSetup.ImageUseCase, useCaseSetup.JobUseCase, useCaseSetup.WebhookUseCase),
+		HistoryController: NewHistoryController(log, useCaseSetup.HistoryUseCase),
+		JobController:     NewJobController(log, useCaseSetup.JobUseCase),
 	}
 }
@@ -2,6 +2,7 @@ package route
 
 import (
 	"go-image-api/internal/delivery/http/controller"
+	"go-image-api/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -10,6 +11,7 @@ import (
 type RouteConfig struct {
 	App             *fiber.App
 	ControllerSetup *controller.ControllerSetup
+	Storage         storage.Storage
 }
 
 func (c *RouteConfig) Setup() {
@@ -23,7 +25,29 @@ func (c *RouteConfig) Setup() {
 	}))
 
 	// Register controller here
+	route.Post("/convert", c.ControllerSetup.ImageController.Convert)
 	route.Post("/convert-png-to-jpeg", c.ControllerSetup.ImageController.ConvertPNGToJPEG)
 	route.Post("/image-resize", c.ControllerSetup.ImageController.Resize)
 	route.Post("/image-compress", c.ControllerSetup.ImageController.Compress)
+	route.Post("/image-crop", c.ControllerSetup.ImageController.Crop)
+	route.Post("/image-rotate", c.ControllerSetup.ImageController.Rotate)
+	route.Post("/image-watermark", c.ControllerSetup.ImageController.Watermark)
+	route.Post("/image-filter", c.ControllerSetup.ImageController.Filter)
+	route.Post("/image-enhance", c.ControllerSetup.ImageController.Enhance)
+	route.Post("/image-denoise", c.ControllerSetup.ImageController.Denoise)
+	route.Post("/transform", c.ControllerSetup.ImageController.Transform)
+	route.Post("/batch/convert-png-to-jpeg", c.ControllerSetup.ImageController.BatchConvertPNGToJPEG)
+	route.Post("/batch/image-resize", c.ControllerSetup.ImageController.BatchResize)
+	route.Post("/batch/image-compress", c.ControllerSetup.ImageController.BatchCompress)
+	route.Get("/histories", c.ControllerSetup.HistoryController.List)
+	route.Get("/histories/:id", c.ControllerSetup.HistoryController.Get)
+	route.Delete("/histories/:id", c.ControllerSetup.HistoryController.Delete)
+	route.Get("/jobs/:id", c.ControllerSetup.JobController.Get)
+
+	// Serve stored images when they are written to local disk
+	if localStorage, ok := c.Storage.(*storage.LocalStorage); ok {
+		c.App.Static(localStorage.Prefix, localStorage.Root, fiber.Static{
+			ByteRange: true,
+		})
+	}
 }
@@ -1,19 +1,29 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type History struct {
-	ID               int `gorm:"primaryKey"`
-	Timestamp        time.Time
-	Type             string
-	ExtensionBefore  string
-	ExtensionAfter   string
-	SizeBeforeInMB   float64
-	SizeAfterInMB    float64
-	HeightBeforeInPx int
-	HeightAfterInPx  int
-	WidthBeforeInPx  int
-	WidthAfterInPx   int
-	ImageLinkBefore  string
-	ImageLinkAfter   string
+	ID                  int `gorm:"primaryKey"`
+	Timestamp           time.Time
+	Type                string
+	ExtensionBefore     string
+	ExtensionAfter      string
+	SizeBeforeInMB      float64
+	SizeAfterInMB       float64
+	HeightBeforeInPx    int
+	HeightAfterInPx     int
+	WidthBeforeInPx     int
+	WidthAfterInPx      int
+	ImageLinkBefore     string
+	ImageLinkAfter      string
+	ImagePublicIDBefore string
+	ImagePublicIDAfter  string
+	WatermarkPublicIDs  []string `gorm:"serializer:json"`
+	Quality             *int
+	SSIM                *float64
+	PSNR                *float64
+	Parameters          json.RawMessage `gorm:"type:jsonb"`
 }
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusPending    = "pending"
+	JobStatusProcessing = "processing"
+	JobStatusSucceeded  = "succeeded"
+	JobStatusFailed     = "failed"
+)
+
+type Job struct {
+	ID          string `gorm:"primaryKey"`
+	Type        string
+	Status      string
+	Payload     json.RawMessage `gorm:"type:jsonb"`
+	Image       []byte
+	FileName    string
+	CallbackURL string
+	Result      json.RawMessage `gorm:"type:jsonb"`
+	Error       json.RawMessage `gorm:"type:jsonb"`
+	Attempts    int
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	HeartbeatAt *time.Time
+	FinishedAt  *time.Time
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	ID            string `gorm:"primaryKey"`
+	URL           string
+	Payload       json.RawMessage `gorm:"type:jsonb"`
+	Status        string
+	Attempts      int
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	FinishedAt    *time.Time
+}
+
+type WebhookAttempt struct {
+	ID                int `gorm:"primaryKey"`
+	WebhookDeliveryID string
+	Number            int
+	StatusCode        int
+	Error             string
+	DurationInMs      int64
+	Timestamp         time.Time
+}
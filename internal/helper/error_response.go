@@ -0,0 +1,50 @@
+package helper
+
+import (
+	"fmt"
+	"go-image-api/internal/model"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Converts error returned by usecase into error response
+func NewErrorResponse(err error) *model.ErrorResponse {
+	response := new(model.ErrorResponse)
+
+	if errConv, ok := err.(validator.ValidationErrors); ok {
+		response.Code = fiber.StatusBadRequest
+
+		for _, errItem := range errConv {
+			switch errItem.Tag() {
+			case "required":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s is required", errItem.Field()))
+			case "min":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s is should more than %d",
+					errItem.Tag(), errItem.Value()))
+			case "max":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s is should me less than %d",
+					errItem.Tag(), errItem.Value()))
+			case "gte":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s should be greater than or equal to %s",
+					errItem.Field(), errItem.Param()))
+			case "lte":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s should be less than or equal to %s",
+					errItem.Field(), errItem.Param()))
+			case "oneof":
+				response.Messages = append(response.Messages, fmt.Sprintf("%s should be one of [%s]",
+					errItem.Field(), errItem.Param()))
+			default:
+				response.Messages = append(response.Messages, fmt.Sprintf("%s is invalid", errItem.Field()))
+			}
+		}
+	} else if errConv, ok := err.(*fiber.Error); ok {
+		response.Code = errConv.Code
+		response.Messages = []string{errConv.Message}
+	} else {
+		response.Code = fiber.StatusInternalServerError
+		response.Messages = []string{"Internal server error"}
+	}
+
+	return response
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Returns EXIF orientation (1-8) of a jpeg or png image, 1 is returned when it is missing or unreadable
+func ExifOrientation(imageBytes []byte) int {
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(imageBytes, []byte{0xFF, 0xD8}):
+		tiff = jpegExif(imageBytes)
+	case bytes.HasPrefix(imageBytes, []byte("\x89PNG\r\n\x1a\n")):
+		tiff = pngExif(imageBytes)
+	}
+
+	orientation := tiffOrientation(tiff)
+	if orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
+
+// Returns TIFF structure of the APP1 Exif segment
+func jpegExif(imageBytes []byte) []byte {
+	for i := 2; i+4 <= len(imageBytes); {
+		if imageBytes[i] != 0xFF {
+			return nil
+		}
+		marker := imageBytes[i+1]
+		// Start of scan, metadata segments are always placed before it
+		if marker == 0xDA {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(imageBytes[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(imageBytes) {
+			return nil
+		}
+		segment := imageBytes[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i = end
+	}
+
+	return nil
+}
+
+// Returns TIFF structure of the eXIf chunk
+func pngExif(imageBytes []byte) []byte {
+	for i := 8; i+8 <= len(imageBytes); {
+		length := int(binary.BigEndian.Uint32(imageBytes[i:]))
+		chunkType := string(imageBytes[i+4 : i+8])
+		end := i + 8 + length
+		if end > len(imageBytes) {
+			return nil
+		}
+		// Metadata after image data is not taken into account by most readers
+		if chunkType == "eXIf" {
+			return imageBytes[i+8 : end]
+		}
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			return nil
+		}
+		// Skip chunk data and CRC
+		i = end + 4
+	}
+
+	return nil
+}
+
+// Reads orientation tag (0x0112) of the first IFD
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	entryCount := int(order.Uint16(tiff[offset:]))
+	for e := 0; e < entryCount; e++ {
+		entry := offset + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+
+	return 0
+}
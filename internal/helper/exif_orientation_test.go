@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+// Builds a TIFF structure whose first IFD holds only the orientation tag
+func testTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := new(bytes.Buffer)
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(tiff, order, uint16(42))
+	binary.Write(tiff, order, uint32(8))
+	// Entry count, then tag, SHORT type, count of 1 and the value left aligned
+	binary.Write(tiff, order, uint16(1))
+	binary.Write(tiff, order, uint16(0x0112))
+	binary.Write(tiff, order, uint16(3))
+	binary.Write(tiff, order, uint32(1))
+	binary.Write(tiff, order, orientation)
+	binary.Write(tiff, order, uint16(0))
+	binary.Write(tiff, order, uint32(0))
+	return tiff.Bytes()
+}
+
+// Builds a jpeg segment, the length covers itself and the data
+func testSegment(marker byte, data []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(data)+2))
+	return append(segment, data...)
+}
+
+func testJPEG(segments ...[]byte) []byte {
+	jpeg := []byte{0xFF, 0xD8}
+	for _, segment := range segments {
+		jpeg = append(jpeg, segment...)
+	}
+	// Start of scan ends the metadata
+	return append(jpeg, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9)
+}
+
+// Builds a png chunk with its length and CRC
+func testChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func testPNG(chunks ...[]byte) []byte {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	png = append(png, testChunk("IHDR", make([]byte, 13))...)
+	for _, chunk := range chunks {
+		png = append(png, chunk...)
+	}
+	return append(png, testChunk("IEND", nil)...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	type testCase struct {
+		name  string
+		image []byte
+		want  int
+	}
+	var tests []testCase
+	for orientation := 1; orientation <= 8; orientation++ {
+		exif := append([]byte("Exif\x00\x00"), testTIFF(binary.LittleEndian, uint16(orientation))...)
+		tests = append(tests,
+			testCase{
+				name:  fmt.Sprintf("jpeg app1 little endian %d", orientation),
+				image: testJPEG(testSegment(0xE0, []byte("JFIF\x00")), testSegment(0xE1, exif)),
+				want:  orientation,
+			},
+			testCase{
+				name:  fmt.Sprintf("png exif big endian %d", orientation),
+				image: testPNG(testChunk("eXIf", testTIFF(binary.BigEndian, uint16(orientation))), testChunk("IDAT", nil)),
+				want:  orientation,
+			},
+		)
+	}
+
+	exif := append([]byte("Exif\x00\x00"), testTIFF(binary.BigEndian, 6)...)
+	oversizedSegment := testSegment(0xE1, exif)
+	binary.BigEndian.PutUint16(oversizedSegment[2:], 0xFFFF)
+	undersizedSegment := testSegment(0xE1, exif)
+	binary.BigEndian.PutUint16(undersizedSegment[2:], 1)
+	oversizedChunk := testChunk("eXIf", testTIFF(binary.LittleEndian, 6))
+	binary.BigEndian.PutUint32(oversizedChunk, 0xFFFFFFFF)
+	oversizedEntries := testTIFF(binary.LittleEndian, 6)
+	binary.LittleEndian.PutUint16(oversizedEntries[8:], 0xFFFF)
+	tests = append(tests, []testCase{
+		{name: "jpeg without exif", image: testJPEG(testSegment(0xE0, []byte("JFIF\x00"))), want: 1},
+		{name: "jpeg app1 without exif header", image: testJPEG(testSegment(0xE1, testTIFF(binary.BigEndian, 6))), want: 1},
+		{name: "jpeg exif after start of scan", image: append(testJPEG(), testSegment(0xE1, exif)...), want: 1},
+		{name: "jpeg oversized segment length", image: testJPEG(oversizedSegment), want: 1},
+		{name: "jpeg undersized segment length", image: testJPEG(undersizedSegment), want: 1},
+		{name: "jpeg truncated segment", image: testJPEG(testSegment(0xE1, exif))[:20], want: 1},
+		{name: "png without exif", image: testPNG(testChunk("IDAT", nil)), want: 1},
+		{name: "png exif after image data", image: testPNG(testChunk("IDAT", nil), testChunk("eXIf", testTIFF(binary.BigEndian, 6))), want: 1},
+		{name: "png oversized chunk length", image: testPNG(oversizedChunk), want: 1},
+		{name: "png truncated chunk", image: testPNG(testChunk("eXIf", testTIFF(binary.BigEndian, 6)))[:45], want: 1},
+		{name: "orientation out of range", image: testPNG(testChunk("eXIf", testTIFF(binary.BigEndian, 9))), want: 1},
+		{name: "oversized entry count", image: testPNG(testChunk("eXIf", oversizedEntries[:20])), want: 1},
+		{name: "invalid byte order", image: testPNG(testChunk("eXIf", append([]byte("XX"), testTIFF(binary.BigEndian, 6)[2:]...))), want: 1},
+		{name: "not an image", image: []byte("plain text"), want: 1},
+		{name: "empty", image: nil, want: 1},
+	}...)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExifOrientation(test.image); got != test.want {
+				t.Errorf("ExifOrientation() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package helper
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Parses #rgb, #rgba, #rrggbb or #rrggbbaa color, alpha is opaque when omitted
+func ParseHexColor(hex string) (color.RGBA, error) {
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) == 3 || len(digits) == 4 {
+		var expanded strings.Builder
+		for _, digit := range digits {
+			expanded.WriteRune(digit)
+			expanded.WriteRune(digit)
+		}
+		digits = expanded.String()
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+	if len(digits) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q", hex)
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q", hex)
+	}
+
+	return color.RGBA{
+		R: uint8(value >> 24),
+		G: uint8(value >> 16),
+		B: uint8(value >> 8),
+		A: uint8(value),
+	}, nil
+}
@@ -0,0 +1,38 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+// Ancillary png chunks which only carry metadata, they do not change how the image is displayed
+var pngMetadataChunks = []string{"tEXt", "zTXt", "iTXt", "tIME", "eXIf"}
+
+// Removes metadata chunks from png bytes, the bytes are returned as is when they are not a valid png
+func StripPNGMetadata(imageBytes []byte) []byte {
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(imageBytes, signature) {
+		return imageBytes
+	}
+
+	stripped := bytes.NewBuffer(make([]byte, 0, len(imageBytes)))
+	stripped.Write(signature)
+	for i := len(signature); i < len(imageBytes); {
+		if i+8 > len(imageBytes) {
+			return imageBytes
+		}
+		length := int(binary.BigEndian.Uint32(imageBytes[i:]))
+		// Chunk is made of length, type, data and CRC
+		end := i + 12 + length
+		if end > len(imageBytes) {
+			return imageBytes
+		}
+		if !slices.Contains(pngMetadataChunks, string(imageBytes[i+4:i+8])) {
+			stripped.Write(imageBytes[i:end])
+		}
+		i = end
+	}
+
+	return stripped.Bytes()
+}
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestStripPNGMetadata(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 128})
+	encoded := new(bytes.Buffer)
+	if err := png.Encode(encoded, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	// Stdlib writes IHDR, IDAT and IEND only, metadata is inserted right after IHDR
+	plain := encoded.Bytes()
+	withMetadata := bytes.Clone(plain[:33])
+	for _, chunk := range [][]byte{
+		testChunk("tEXt", []byte("Comment\x00hello")),
+		testChunk("tIME", make([]byte, 7)),
+		testChunk("eXIf", testTIFF(binary.BigEndian, 6)),
+		testChunk("iTXt", []byte("Title\x00\x00\x00\x00\x00title")),
+		testChunk("zTXt", []byte("Text\x00\x00")),
+	} {
+		withMetadata = append(withMetadata, chunk...)
+	}
+	withMetadata = append(withMetadata, plain[33:]...)
+
+	oversized := bytes.Clone(withMetadata)
+	binary.BigEndian.PutUint32(oversized[33:], 0xFFFFFFFF)
+
+	tests := []struct {
+		name  string
+		image []byte
+		want  []byte
+	}{
+		{name: "metadata chunks are removed", image: withMetadata, want: plain},
+		{name: "png without metadata is unchanged", image: plain, want: plain},
+		{name: "oversized chunk length is returned as is", image: oversized, want: oversized},
+		{name: "truncated chunk is returned as is", image: withMetadata[:50], want: withMetadata[:50]},
+		{name: "truncated chunk header is returned as is", image: withMetadata[:36], want: withMetadata[:36]},
+		{name: "not a png is returned as is", image: []byte("plain text"), want: []byte("plain text")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := StripPNGMetadata(test.image)
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("StripPNGMetadata() = %d bytes, want %d bytes", len(got), len(test.want))
+			}
+		})
+	}
+
+	// Stripped png still decodes into the same pixels
+	decoded, err := png.Decode(bytes.NewReader(StripPNGMetadata(withMetadata)))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if got := color.NRGBAModel.Convert(decoded.At(1, 1)); got != img.At(1, 1) {
+		t.Errorf("decoded pixel = %v, want %v", got, img.At(1, 1))
+	}
+}
@@ -0,0 +1,187 @@
+package helper
+
+import (
+	"image"
+	"image/color"
+	"slices"
+)
+
+// Maximum number of pixels sampled to build the palette, larger images are sampled with a stride
+const quantizeMaxSamples = 1 << 18
+
+// Reduces the image into a palette of at most the given colors (2-256) using median cut,
+// optionally spreading the quantization error with Floyd-Steinberg dithering
+func Quantize(img *image.NRGBA, colors int, dither bool) *image.Paletted {
+	bounds := img.Bounds()
+	palette := medianCut(samplePixels(img), colors)
+	index := newPaletteIndex(palette)
+	quantized := image.NewPaletted(bounds, palette)
+
+	if !dither {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				offset := img.PixOffset(x, y)
+				quantized.SetColorIndex(x, y, index.nearest(
+					int32(img.Pix[offset]), int32(img.Pix[offset+1]), int32(img.Pix[offset+2]), int32(img.Pix[offset+3])))
+			}
+		}
+		return quantized
+	}
+
+	// Error of the current and next row, 4 channels per pixel with a pixel of padding on both sides
+	width := bounds.Dx()
+	current := make([]int32, (width+2)*4)
+	next := make([]int32, (width+2)*4)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		clear(next)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			offset := img.PixOffset(x, y)
+			e := (x - bounds.Min.X + 1) * 4
+
+			var pixel [4]int32
+			for c := 0; c < 4; c++ {
+				pixel[c] = clamp8(int32(img.Pix[offset+c]) + current[e+c]/16)
+			}
+			i := index.nearest(pixel[0], pixel[1], pixel[2], pixel[3])
+			quantized.SetColorIndex(x, y, i)
+
+			chosen := palette[i].(color.NRGBA)
+			for c, value := range [4]int32{int32(chosen.R), int32(chosen.G), int32(chosen.B), int32(chosen.A)} {
+				diff := pixel[c] - value
+				current[e+4+c] += diff * 7
+				next[e-4+c] += diff * 3
+				next[e+c] += diff * 5
+				next[e+4+c] += diff
+			}
+		}
+		current, next = next, current
+	}
+
+	return quantized
+}
+
+// Collects pixels of the image, every pixel when it is small enough
+func samplePixels(img *image.NRGBA) [][4]uint8 {
+	bounds := img.Bounds()
+	stride := max(bounds.Dx()*bounds.Dy()/quantizeMaxSamples, 1)
+
+	pixels := make([][4]uint8, 0, min(bounds.Dx()*bounds.Dy(), quantizeMaxSamples+1))
+	for i := 0; i < bounds.Dx()*bounds.Dy(); i += stride {
+		offset := img.PixOffset(bounds.Min.X+i%bounds.Dx(), bounds.Min.Y+i/bounds.Dx())
+		pixels = append(pixels, [4]uint8(img.Pix[offset:offset+4]))
+	}
+
+	return pixels
+}
+
+// Splits the pixels into boxes at the median of their widest channel, until there are enough boxes,
+// then averages every box into a palette color
+func medianCut(pixels [][4]uint8, colors int) color.Palette {
+	boxes := [][][4]uint8{pixels}
+	for len(boxes) < colors {
+		// Split the box with the widest channel range, weighted by its size
+		widest, widestChannel, widestScore := -1, 0, 0
+		for b, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, spread := widestRange(box)
+			if score := spread * len(box); spread > 0 && score > widestScore {
+				widest, widestChannel, widestScore = b, channel, score
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		box := boxes[widest]
+		slices.SortFunc(box, func(a, b [4]uint8) int {
+			return int(a[widestChannel]) - int(b[widestChannel])
+		})
+		boxes[widest] = box[:len(box)/2]
+		boxes = append(boxes, box[len(box)/2:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [4]int
+		for _, pixel := range box {
+			for c := 0; c < 4; c++ {
+				sum[c] += int(pixel[c])
+			}
+		}
+		n := max(len(box), 1)
+		palette = append(palette, color.NRGBA{
+			R: uint8(sum[0] / n),
+			G: uint8(sum[1] / n),
+			B: uint8(sum[2] / n),
+			A: uint8(sum[3] / n),
+		})
+	}
+
+	return palette
+}
+
+// Returns the channel with the widest range of values and the range
+func widestRange(box [][4]uint8) (int, int) {
+	low := box[0]
+	high := box[0]
+	for _, pixel := range box {
+		for c := 0; c < 4; c++ {
+			low[c] = min(low[c], pixel[c])
+			high[c] = max(high[c], pixel[c])
+		}
+	}
+
+	channel, spread := 0, 0
+	for c := 0; c < 4; c++ {
+		if int(high[c])-int(low[c]) > spread {
+			channel, spread = c, int(high[c])-int(low[c])
+		}
+	}
+	return channel, spread
+}
+
+// Finds the nearest palette color, cached by color reduced to 5 bits per channel
+type paletteIndex struct {
+	palette []color.NRGBA
+	cache   []int16
+}
+
+func newPaletteIndex(palette color.Palette) *paletteIndex {
+	index := &paletteIndex{
+		palette: make([]color.NRGBA, len(palette)),
+		cache:   make([]int16, 1<<20),
+	}
+	for i, c := range palette {
+		index.palette[i] = c.(color.NRGBA)
+	}
+	for i := range index.cache {
+		index.cache[i] = -1
+	}
+
+	return index
+}
+
+func (p *paletteIndex) nearest(r, g, b, a int32) uint8 {
+	key := (r>>3)<<15 | (g>>3)<<10 | (b>>3)<<5 | a>>3
+	if cached := p.cache[key]; cached >= 0 {
+		return uint8(cached)
+	}
+
+	best, bestDistance := 0, int32(-1)
+	for i, c := range p.palette {
+		dr, dg, db, da := r-int32(c.R), g-int32(c.G), b-int32(c.B), a-int32(c.A)
+		distance := dr*dr + dg*dg + db*db + da*da
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	p.cache[key] = int16(best)
+
+	return uint8(best)
+}
+
+func clamp8(value int32) int32 {
+	return min(max(value, 0), 255)
+}
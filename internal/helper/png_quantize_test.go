@@ -0,0 +1,110 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// Builds an image with a color and alpha gradient, so it holds far more colors than any palette
+func testGradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * 255 / (width - 1)),
+				G: uint8(y * 255 / (height - 1)),
+				B: uint8((x + y) * 255 / (width + height - 2)),
+				A: uint8(255 - y*128/(height-1)),
+			})
+		}
+	}
+	return img
+}
+
+func TestQuantize(t *testing.T) {
+	tests := []struct {
+		name   string
+		img    *image.NRGBA
+		colors int
+		dither bool
+	}{
+		{name: "gradient into 2 colors", img: testGradient(64, 48), colors: 2},
+		{name: "gradient into 16 colors", img: testGradient(64, 48), colors: 16},
+		{name: "gradient into 256 colors", img: testGradient(64, 48), colors: 256},
+		{name: "gradient into 16 colors dithered", img: testGradient(64, 48), colors: 16, dither: true},
+		{name: "gradient into 256 colors dithered", img: testGradient(64, 48), colors: 256, dither: true},
+		{name: "offset bounds", img: testGradient(64, 48).SubImage(image.Rect(10, 5, 50, 40)).(*image.NRGBA), colors: 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quantized := Quantize(test.img, test.colors, test.dither)
+			if len(quantized.Palette) > test.colors {
+				t.Fatalf("Quantize() palette has %d colors, want at most %d", len(quantized.Palette), test.colors)
+			}
+			if quantized.Bounds() != test.img.Bounds() {
+				t.Fatalf("Quantize() bounds = %v, want %v", quantized.Bounds(), test.img.Bounds())
+			}
+
+			// Round trip through png keeps every pixel within the palette
+			encoded := new(bytes.Buffer)
+			if err := png.Encode(encoded, quantized); err != nil {
+				t.Fatalf("png.Encode() error = %v", err)
+			}
+			decoded, err := png.Decode(encoded)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+			palette := make(map[color.NRGBA]bool, len(quantized.Palette))
+			for _, c := range quantized.Palette {
+				palette[color.NRGBAModel.Convert(c).(color.NRGBA)] = true
+			}
+			seen := make(map[color.NRGBA]bool)
+			bounds := decoded.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					c := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					if !palette[c] {
+						t.Fatalf("decoded pixel (%d, %d) = %v is not in the palette", x, y, c)
+					}
+					seen[c] = true
+				}
+			}
+			if len(seen) > test.colors {
+				t.Errorf("decoded image has %d colors, want at most %d", len(seen), test.colors)
+			}
+		})
+	}
+}
+
+func TestQuantizeKeepsFewColors(t *testing.T) {
+	want := []color.NRGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 10, G: 20, B: 30, A: 0},
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetNRGBA(x, y, want[(x+y)%len(want)])
+		}
+	}
+
+	for _, dither := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dither %t", dither), func(t *testing.T) {
+			quantized := Quantize(img, 16, dither)
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					if got := quantized.At(x, y); got != want[(x+y)%len(want)] {
+						t.Fatalf("Quantize() pixel (%d, %d) = %v, want %v", x, y, got, want[(x+y)%len(want)])
+					}
+				}
+			}
+		})
+	}
+}
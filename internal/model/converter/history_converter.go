@@ -0,0 +1,28 @@
+package converter
+
+import (
+	"go-image-api/internal/entity"
+	"go-image-api/internal/model"
+)
+
+func HistoryToResponse(history *entity.History) *model.History {
+	return &model.History{
+		ID:               history.ID,
+		Timestamp:        history.Timestamp,
+		Type:             history.Type,
+		ExtensionBefore:  history.ExtensionBefore,
+		ExtensionAfter:   history.ExtensionAfter,
+		SizeBeforeInMB:   history.SizeBeforeInMB,
+		SizeAfterInMB:    history.SizeAfterInMB,
+		HeightBeforeInPx: history.HeightBeforeInPx,
+		HeightAfterInPx:  history.HeightAfterInPx,
+		WidthBeforeInPx:  history.WidthBeforeInPx,
+		WidthAfterInPx:   history.WidthAfterInPx,
+		ImageLinkBefore:  history.ImageLinkBefore,
+		ImageLinkAfter:   history.ImageLinkAfter,
+		Quality:          history.Quality,
+		SSIM:             history.SSIM,
+		PSNR:             history.PSNR,
+		Parameters:       history.Parameters,
+	}
+}
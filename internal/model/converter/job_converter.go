@@ -0,0 +1,36 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-image-api/internal/entity"
+	"go-image-api/internal/model"
+)
+
+func JobToResponse(job *entity.Job) *model.JobResponse {
+	response := &model.JobResponse{
+		ID:         job.ID,
+		Type:       job.Type,
+		Status:     job.Status,
+		FileName:   job.FileName,
+		Attempts:   job.Attempts,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+
+	// Result and error are stored as JSON, an unreadable one is left out rather than failing the response
+	if len(job.Result) > 0 {
+		result := new(model.ImageResponse)
+		if err := json.Unmarshal(job.Result, result); err == nil {
+			response.Result = result
+		}
+	}
+	if len(job.Error) > 0 {
+		jobError := new(model.ErrorResponse)
+		if err := json.Unmarshal(job.Error, jobError); err == nil {
+			response.Error = jobError
+		}
+	}
+
+	return response
+}
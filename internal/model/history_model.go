@@ -1,19 +1,48 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type History struct {
-	ID               int       `json:"id"`
-	Timestamp        time.Time `json:"timestamp"`
-	Type             string    `json:"type"`
-	ExtensionBefore  string    `json:"extension_before"`
-	ExtensionAfter   string    `json:"extension_after"`
-	SizeBeforeInMB   int       `json:"size_before_in_mb"`
-	SizeAfterInMB    int       `json:"size_after_in_mb"`
-	HeightBeforeInPx int       `json:"height_before_in_px"`
-	HeightAfterInPx  int       `json:"height_after_in_px"`
-	WidthBeforeInPx  int       `json:"width_before_in_px"`
-	WidthAfterInPx   int       `json:"width_after_in_px"`
-	IsSuccess        bool      `json:"is_success"`
-	ImageLink        string    `json:"image_link"`
+	ID               int             `json:"id"`
+	Timestamp        time.Time       `json:"timestamp"`
+	Type             string          `json:"type"`
+	ExtensionBefore  string          `json:"extension_before"`
+	ExtensionAfter   string          `json:"extension_after"`
+	SizeBeforeInMB   float64         `json:"size_before_in_mb"`
+	SizeAfterInMB    float64         `json:"size_after_in_mb"`
+	HeightBeforeInPx int             `json:"height_before_in_px"`
+	HeightAfterInPx  int             `json:"height_after_in_px"`
+	WidthBeforeInPx  int             `json:"width_before_in_px"`
+	WidthAfterInPx   int             `json:"width_after_in_px"`
+	ImageLinkBefore  string          `json:"image_link_before"`
+	ImageLinkAfter   string          `json:"image_link_after"`
+	Quality          *int            `json:"quality,omitempty"`
+	SSIM             *float64        `json:"ssim,omitempty"`
+	PSNR             *float64        `json:"psnr,omitempty"`
+	Parameters       json.RawMessage `json:"parameters,omitempty"`
+}
+
+type GetHistoryRequest struct {
+	ID int `json:"-" validate:"required,gte=1"`
+}
+
+type DeleteHistoryRequest struct {
+	ID int `json:"-" validate:"required,gte=1"`
+}
+
+type SearchHistoryRequest struct {
+	Page            int        `json:"-" validate:"gte=1"`
+	Size            int        `json:"-" validate:"gte=1,lte=100"`
+	Type            string     `json:"-" validate:"max=100"`
+	ExtensionBefore string     `json:"-" validate:"max=100"`
+	ExtensionAfter  string     `json:"-" validate:"max=100"`
+	TimestampFrom   *time.Time `json:"-"`
+	TimestampTo     *time.Time `json:"-"`
+	MinSizeInMB     *float64   `json:"-" validate:"omitempty,gte=0"`
+	MaxSizeInMB     *float64   `json:"-" validate:"omitempty,gte=0"`
+	SortBy          string     `json:"-" validate:"required,oneof=id timestamp type size_before_in_mb size_after_in_mb"`
+	SortOrder       string     `json:"-" validate:"required,oneof=asc desc"`
 }
@@ -1,25 +1,227 @@
 package model
 
 import (
+	"encoding/json"
 	"mime/multipart"
 )
 
+// Options honored by every operation when the image is decoded
+type OrientParams struct {
+	AutoOrient bool `json:"auto_orient,omitempty"` // Applies EXIF orientation, so the result matches what the user saw
+}
+
+// Options of how transparency is handled when the target format can not keep it
+type AlphaParams struct {
+	BackgroundColor string `json:"background_color,omitempty" validate:"omitempty,hexcolor"` // Transparency is flattened onto it for jpeg and gif
+	TrimTransparent bool   `json:"trim_transparent,omitempty"`
+}
+
 type ImageRequest struct {
+	AlphaParams
+	JPEGParams
+	OrientParams
 	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
 }
 
+type ResizeParams struct {
+	WidthInPixels   int    `json:"width_in_pixels" validate:"gte=0,lte=3000"`  // When empty, derived from height preserving ratio
+	HeightInPixels  int    `json:"height_in_pixels" validate:"gte=0,lte=3000"` // When empty, derived from width preserving ratio
+	Mode            string `json:"mode,omitempty" validate:"omitempty,oneof=fill contain cover pad"`
+	NoUpscale       bool   `json:"no_upscale,omitempty"`
+	Interpolation   string `json:"interpolation,omitempty" validate:"omitempty,oneof=nearest linear cubic area lanczos4"`
+	Gravity         string `json:"gravity,omitempty" validate:"omitempty,oneof=center north south east west north-east north-west south-east south-west smart"`
+	BackgroundColor string `json:"background_color,omitempty" validate:"omitempty,hexcolor"`
+	OrientParams
+}
+
 type ImageResizeRequest struct {
-	WidthInPixels   int                   `json:"-" validate:"required,gte=1,lte=3000"`
-	HeightInPixels  int                   `json:"-" validate:"required,gte=1,lte=3000"`
+	ResizeParams
 	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
 }
 
+// Advanced JPEG encoder options, ignored for other formats
+type JPEGParams struct {
+	Progressive       bool   `json:"progressive,omitempty"`
+	Optimize          bool   `json:"optimize,omitempty"`                                          // Optimized Huffman tables
+	LumaQuality       int    `json:"luma_quality,omitempty" validate:"omitempty,gte=1,lte=100"`   // Replaces the quality of the luma channel
+	ChromaQuality     int    `json:"chroma_quality,omitempty" validate:"omitempty,gte=1,lte=100"` // When empty set to luma quality
+	ChromaSubsampling string `json:"chroma_subsampling,omitempty" validate:"omitempty,oneof=420 422 444"`
+	RestartInterval   int    `json:"restart_interval,omitempty" validate:"omitempty,gte=1,lte=65535"` // In MCUs, 0 disables restart markers
+}
+
+type CompressParams struct {
+	CompressQuality     int     `json:"compress_quality" validate:"omitempty,gte=1,lte=99"`
+	PNGCompressionLevel int     `json:"png_compression_level,omitempty" validate:"omitempty,gte=1,lte=9"` // zlib level, when empty set to 9
+	PNGColors           int     `json:"png_colors,omitempty" validate:"omitempty,gte=2,lte=256"`          // Palette size, when empty derived from quality
+	PNGDither           bool    `json:"png_dither,omitempty"`
+	TargetSizeInKB      int     `json:"target_size_kb,omitempty" validate:"omitempty,gte=1"` // Searches the highest quality fitting it, replacing compress quality
+	AllowDownscale      bool    `json:"allow_downscale,omitempty"`                           // Downscales as a last resort when the lowest quality does not fit
+	MinSSIM             float64 `json:"min_ssim,omitempty" validate:"omitempty,gt=0,lte=1"`  // Searches the lowest quality keeping SSIM at least this
+	JPEGParams
+	OrientParams
+}
+
 type ImageCompressRequest struct {
-	CompressQuality int                   `json:"-" validate:"required,gte=1,lte=99"`
+	CompressParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type CropParams struct {
+	Mode           string  `json:"mode" validate:"required,oneof=pixel percent gravity"`
+	X              int     `json:"x,omitempty" validate:"gte=0"`
+	Y              int     `json:"y,omitempty" validate:"gte=0"`
+	WidthInPixels  int     `json:"width_in_pixels,omitempty" validate:"gte=0"`
+	HeightInPixels int     `json:"height_in_pixels,omitempty" validate:"gte=0"`
+	XPercent       float64 `json:"x_percent,omitempty" validate:"gte=0,lte=100"`
+	YPercent       float64 `json:"y_percent,omitempty" validate:"gte=0,lte=100"`
+	WidthPercent   float64 `json:"width_percent,omitempty" validate:"gte=0,lte=100"`
+	HeightPercent  float64 `json:"height_percent,omitempty" validate:"gte=0,lte=100"`
+	Gravity        string  `json:"gravity,omitempty" validate:"omitempty,oneof=center north south east west north-east north-west south-east south-west smart"`
+	OrientParams
+}
+
+type ImageCropRequest struct {
+	CropParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type RotateParams struct {
+	AngleInDegrees  float64 `json:"angle_in_degrees" validate:"gte=-360,lte=360"` // Clockwise, right angles are rotated losslessly
+	Flip            string  `json:"flip,omitempty" validate:"omitempty,oneof=horizontal vertical both"`
+	BackgroundColor string  `json:"background_color,omitempty" validate:"omitempty,hexcolor"` // Fills the uncovered corners of arbitrary angles
+	OrientParams
+}
+
+type ImageRotateRequest struct {
+	RotateParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type WatermarkParams struct {
+	Text           string  `json:"text,omitempty" validate:"omitempty,max=200"`
+	TextColor      string  `json:"text_color,omitempty" validate:"omitempty,hexcolor"`
+	Image          []byte  `json:"image,omitempty"`      // Png logo, base64 encoded in JSON, either it or text is required
+	ImageLink      string  `json:"image_link,omitempty"` // Stored logo, set in place of the logo when recorded in history
+	Gravity        string  `json:"gravity,omitempty" validate:"omitempty,oneof=center north south east west north-east north-west south-east south-west"`
+	MarginInPixels int     `json:"margin_in_pixels,omitempty" validate:"gte=0,lte=1000"`
+	Opacity        float64 `json:"opacity,omitempty" validate:"omitempty,gt=0,lte=1"`
+	Scale          float64 `json:"scale,omitempty" validate:"omitempty,gt=0,lte=1"` // Watermark width relative to the image width
+	Tiled          bool    `json:"tiled,omitempty"`                                 // Repeats the watermark over the whole image, spaced by margin
+	OrientParams
+}
+
+type ImageWatermarkRequest struct {
+	WatermarkParams
+	ImageFileHeader     *multipart.FileHeader `json:"-" validate:"required"`
+	WatermarkFileHeader *multipart.FileHeader `json:"-"`
+}
+
+// Filters are applied in order of grayscale, contrast and brightness, gamma, blur, then sharpen
+type FilterParams struct {
+	Grayscale  bool    `json:"grayscale,omitempty"`
+	Brightness float64 `json:"brightness,omitempty" validate:"gte=-255,lte=255"` // Added to every color channel
+	Contrast   float64 `json:"contrast,omitempty" validate:"gte=0,lte=5"`        // Multiplier of every color channel, when empty set to 1
+	Gamma      float64 `json:"gamma,omitempty" validate:"omitempty,gte=0.1,lte=10"`
+	Blur       float64 `json:"blur,omitempty" validate:"gte=0,lte=100"`   // Gaussian sigma in pixels
+	Sharpen    float64 `json:"sharpen,omitempty" validate:"gte=0,lte=10"` // Unsharp mask amount
+	OrientParams
+}
+
+type ImageFilterRequest struct {
+	FilterParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type EnhanceParams struct {
+	Method       string  `json:"method,omitempty" validate:"omitempty,oneof=equalize clahe"` // When empty set to clahe
+	ColorSpace   string  `json:"color_space,omitempty" validate:"omitempty,oneof=lab ycrcb"` // Space whose luminance is enhanced, when empty set to lab
+	ClipLimit    float64 `json:"clip_limit,omitempty" validate:"omitempty,gte=0.1,lte=40"`   // CLAHE only, when empty set to 2
+	TileGridSize int     `json:"tile_grid_size,omitempty" validate:"omitempty,gte=1,lte=64"` // CLAHE only, tiles per side, when empty set to 8
+	OrientParams
+}
+
+type ImageEnhanceRequest struct {
+	EnhanceParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type DenoiseParams struct {
+	Strength           float64 `json:"strength,omitempty" validate:"omitempty,gte=1,lte=50"`       // Filter strength of luminance, when empty set to 10
+	ColorStrength      float64 `json:"color_strength,omitempty" validate:"omitempty,gte=1,lte=50"` // Filter strength of color, when empty set to strength
+	TemplateWindowSize int     `json:"template_window_size,omitempty" validate:"omitempty,gte=3,lte=21"`
+	SearchWindowSize   int     `json:"search_window_size,omitempty" validate:"omitempty,gte=7,lte=35"`
+	OrientParams
+}
+
+type ImageDenoiseRequest struct {
+	DenoiseParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type ConvertParams struct {
+	TargetFormat string `json:"target_format" validate:"required,oneof=png jpeg webp bmp tiff gif"`
+	AlphaParams
+	JPEGParams
+	OrientParams
+}
+
+type ImageConvertRequest struct {
+	ConvertParams
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+// Single step of a transform pipeline, its parameters are the remaining fields of the operation object
+type TransformOperation struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"-"`
+}
+
+func (o *TransformOperation) UnmarshalJSON(data []byte) error {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+
+	o.Type = header.Type
+	o.Params = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (o TransformOperation) MarshalJSON() ([]byte, error) {
+	if o.Params == nil {
+		return json.Marshal(map[string]string{"type": o.Type})
+	}
+
+	return o.Params, nil
+}
+
+type TransformParams struct {
+	Operations []TransformOperation `json:"operations" validate:"required,min=1,max=10"`
+	OrientParams
+}
+
+type ImageTransformRequest struct {
+	TransformParams
 	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
 }
 
 type ImageResponse struct {
-	OriginalImageLink string `json:"original_image_link"`
-	ResultImageLink   string `json:"result_image_link"`
+	OriginalImageLink string   `json:"original_image_link"`
+	ResultImageLink   string   `json:"result_image_link"`
+	Quality           int      `json:"quality,omitempty"`    // Encoder quality of compressed result
+	SizeInKB          float64  `json:"size_in_kb,omitempty"` // Size of compressed result
+	SSIM              *float64 `json:"ssim,omitempty"`       // Structural similarity of the result against the original, 0-1
+	PSNR              *float64 `json:"psnr,omitempty"`       // Peak signal to noise ratio of the result against the original, in dB
+}
+
+type BatchItemResponse struct {
+	FileName string         `json:"file_name"`
+	Result   *ImageResponse `json:"result,omitempty"`
+	Error    *ErrorResponse `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Data []BatchItemResponse `json:"data"`
 }
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"mime/multipart"
+	"time"
+)
+
+type EnqueueJobRequest struct {
+	Type            string                `json:"-" validate:"required,oneof=convert_png_jpeg convert_image resize_image compress_image crop_image rotate_image watermark_image filter_image enhance_image denoise_image transform_image"`
+	Params          any                   `json:"-"` // Parameters of the operation, validated and stored as job payload
+	CallbackURL     string                `json:"-" validate:"omitempty,http_url,max=2048"`
+	ImageFileHeader *multipart.FileHeader `json:"-" validate:"required"`
+}
+
+type GetJobRequest struct {
+	ID string `json:"-" validate:"required,uuid"`
+}
+
+type JobResponse struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Status     string         `json:"status"`
+	FileName   string         `json:"file_name"`
+	Attempts   int            `json:"attempts"`
+	Result     *ImageResponse `json:"result,omitempty"`
+	Error      *ErrorResponse `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
@@ -4,3 +4,15 @@ type ErrorResponse struct {
 	Code     int      `json:"code"`
 	Messages []string `json:"messages"`
 }
+
+type PageMetadata struct {
+	Page      int   `json:"page"`
+	Size      int   `json:"size"`
+	TotalItem int64 `json:"total_item"`
+	TotalPage int64 `json:"total_page"`
+}
+
+type PageResponse[T any] struct {
+	Data   []T          `json:"data"`
+	Paging PageMetadata `json:"paging"`
+}
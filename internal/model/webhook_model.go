@@ -0,0 +1,6 @@
+package model
+
+type WebhookRequest struct {
+	CallbackURL string `json:"-" validate:"required,http_url,max=2048"`
+	Payload     any    `json:"-" validate:"required"`
+}
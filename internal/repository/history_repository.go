@@ -1,6 +1,12 @@
 package repository
 
-import "go-image-api/internal/entity"
+import (
+	"go-image-api/internal/entity"
+	"go-image-api/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type HistoryRepository struct {
 	Repository[entity.History]
@@ -9,3 +15,61 @@ type HistoryRepository struct {
 func NewHistoryRepository() *HistoryRepository {
 	return new(HistoryRepository)
 }
+
+func (r *HistoryRepository) Search(tx *gorm.DB, request *model.SearchHistoryRequest) ([]entity.History, int64, error) {
+	var histories []entity.History
+	if err := r.FindAll(tx, &histories, r.filter(request), r.sort(request),
+		Paginate(request.Page, request.Size)); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.Count(tx, r.filter(request))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return histories, total, nil
+}
+
+func (r *HistoryRepository) filter(request *model.SearchHistoryRequest) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if request.Type != "" {
+			tx = tx.Where("type = ?", request.Type)
+		}
+		if request.ExtensionBefore != "" {
+			tx = tx.Where("extension_before = ?", request.ExtensionBefore)
+		}
+		if request.ExtensionAfter != "" {
+			tx = tx.Where("extension_after = ?", request.ExtensionAfter)
+		}
+		if request.TimestampFrom != nil {
+			tx = tx.Where("timestamp >= ?", *request.TimestampFrom)
+		}
+		if request.TimestampTo != nil {
+			tx = tx.Where("timestamp <= ?", *request.TimestampTo)
+		}
+		if request.MinSizeInMB != nil {
+			tx = tx.Where("size_before_in_mb >= ?", *request.MinSizeInMB)
+		}
+		if request.MaxSizeInMB != nil {
+			tx = tx.Where("size_before_in_mb <= ?", *request.MaxSizeInMB)
+		}
+
+		return tx
+	}
+}
+
+func (r *HistoryRepository) sort(request *model.SearchHistoryRequest) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		// Sort column is validated against a fixed list, so it is safe to be used as column name
+		desc := request.SortOrder == "desc"
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: request.SortBy}, Desc: desc})
+
+		// Break ties by ID, so pages are stable when the sort column has duplicated values
+		if request.SortBy != "id" {
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+		}
+
+		return tx
+	}
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"go-image-api/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	Repository[entity.Job]
+}
+
+func NewJobRepository() *JobRepository {
+	return new(JobRepository)
+}
+
+// Locks the oldest job waiting to be processed, skipping jobs locked by other workers.
+// Jobs processing without a heartbeat since before staleBefore are picked again, as their worker is assumed to be gone.
+func (r *JobRepository) LockNext(tx *gorm.DB, job *entity.Job, staleBefore time.Time) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ?", entity.JobStatusPending).
+		Or("status = ? AND COALESCE(heartbeat_at, started_at) < ?", entity.JobStatusProcessing, staleBefore).
+		Order("created_at").
+		First(job).Error
+}
+
+// Updates every column changed by the worker, including the ones reset to zero value
+func (r *JobRepository) Save(tx *gorm.DB, job *entity.Job) error {
+	return tx.Model(job).Select("status", "image", "result", "error", "attempts", "started_at", "heartbeat_at", "finished_at").
+		Updates(job).Error
+}
+
+// Refreshes the heartbeat of the job while it is processing, so it is not picked again as stale
+func (r *JobRepository) Heartbeat(db *gorm.DB, id string, heartbeatAt time.Time) error {
+	return db.Model(new(entity.Job)).Where("id = ? AND status = ?", id, entity.JobStatusProcessing).
+		Update("heartbeat_at", heartbeatAt).Error
+}
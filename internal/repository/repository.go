@@ -9,6 +9,16 @@ func (r *Repository[T]) FindByID(tx *gorm.DB, e *T, ID any) error {
 	return tx.First(e, "id = ?", ID).Error
 }
 
+func (r *Repository[T]) FindAll(tx *gorm.DB, e *[]T, scopes ...func(*gorm.DB) *gorm.DB) error {
+	return tx.Scopes(scopes...).Find(e).Error
+}
+
+func (r *Repository[T]) Count(tx *gorm.DB, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	err := tx.Model(new(T)).Scopes(scopes...).Count(&total).Error
+	return total, err
+}
+
 func (r *Repository[T]) Create(tx *gorm.DB, e *T) error {
 	return tx.Create(e).Error
 }
@@ -20,3 +30,10 @@ func (r *Repository[T]) Update(tx *gorm.DB, e *T) error {
 func (r *Repository[T]) Delete(tx *gorm.DB, e *T) error {
 	return tx.Delete(e).Error
 }
+
+// Limits the query into the requested page
+func Paginate(page int, size int) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Offset((page - 1) * size).Limit(size)
+	}
+}
@@ -2,10 +2,18 @@ package repository
 
 type RepositorySetup struct {
 	HistoryRepository *HistoryRepository
+	JobRepository     *JobRepository
+
+	WebhookDeliveryRepository *WebhookDeliveryRepository
+	WebhookAttemptRepository  *WebhookAttemptRepository
 }
 
 func Setup() *RepositorySetup {
 	return &RepositorySetup{
 		HistoryRepository: NewHistoryRepository(),
+		JobRepository:     NewJobRepository(),
+
+		WebhookDeliveryRepository: NewWebhookDeliveryRepository(),
+		WebhookAttemptRepository:  NewWebhookAttemptRepository(),
 	}
 }
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"go-image-api/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookDeliveryRepository struct {
+	Repository[entity.WebhookDelivery]
+}
+
+func NewWebhookDeliveryRepository() *WebhookDeliveryRepository {
+	return new(WebhookDeliveryRepository)
+}
+
+// Locks the pending delivery which is due the earliest, skipping deliveries locked by other workers
+func (r *WebhookDeliveryRepository) LockNext(tx *gorm.DB, delivery *entity.WebhookDelivery, now time.Time) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", entity.WebhookStatusPending, now).
+		Order("next_attempt_at").
+		First(delivery).Error
+}
+
+type WebhookAttemptRepository struct {
+	Repository[entity.WebhookAttempt]
+}
+
+func NewWebhookAttemptRepository() *WebhookAttemptRepository {
+	return new(WebhookAttemptRepository)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+type CloudinaryStorage struct {
+	Cloudinary *cloudinary.Cloudinary
+}
+
+func NewCloudinaryStorage(cld *cloudinary.Cloudinary) *CloudinaryStorage {
+	return &CloudinaryStorage{
+		Cloudinary: cld,
+	}
+}
+
+func (s *CloudinaryStorage) Put(ctx context.Context, publicID string, content io.Reader, contentType string) (string, error) {
+	response, err := s.Cloudinary.Upload.Upload(ctx, content, uploader.UploadParams{
+		PublicID: publicID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if response.Error.Message != "" {
+		return "", errors.New(response.Error.Message)
+	}
+
+	return response.SecureURL, nil
+}
+
+func (s *CloudinaryStorage) Get(ctx context.Context, publicID string) (io.ReadCloser, error) {
+	url, err := s.URL(ctx, publicID)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected status fetching %s : %s", publicID, response.Status)
+	}
+
+	return response.Body, nil
+}
+
+func (s *CloudinaryStorage) Delete(ctx context.Context, publicID string) error {
+	response, err := s.Cloudinary.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID: publicID,
+	})
+	if err != nil {
+		return err
+	}
+	if response.Error.Message != "" {
+		return errors.New(response.Error.Message)
+	}
+
+	return nil
+}
+
+func (s *CloudinaryStorage) URL(ctx context.Context, publicID string) (string, error) {
+	asset, err := s.Cloudinary.Image(publicID)
+	if err != nil {
+		return "", err
+	}
+	asset.Config.URL.Secure = true
+
+	return asset.String()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalStorage struct {
+	Root    string // Directory where the files are written
+	Prefix  string // Route prefix the files are served from
+	BaseURL string // Public address of the app, prepended to the generated URLs
+}
+
+func NewLocalStorage(root string, prefix string, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		Root:    root,
+		Prefix:  "/" + strings.Trim(prefix, "/"),
+		BaseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, publicID string, content io.Reader, contentType string) (string, error) {
+	path, err := s.path(publicID)
+	if err != nil {
+		return "", err
+	}
+
+	// Write into temporary file first, so a failed upload never leaves partial file behind
+	tmpFile, err := os.CreateTemp(s.Root, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, content); err != nil {
+		tmpFile.Close()
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return "", err
+	}
+
+	return s.URL(ctx, publicID)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, publicID string) (io.ReadCloser, error) {
+	path, err := s.path(publicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, publicID string) error {
+	path, err := s.path(publicID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) URL(ctx context.Context, publicID string) (string, error) {
+	if _, err := s.path(publicID); err != nil {
+		return "", err
+	}
+
+	return s.BaseURL + s.Prefix + "/" + publicID, nil
+}
+
+// Resolves public ID into file path, rejecting IDs which would escape the root directory
+func (s *LocalStorage) path(publicID string) (string, error) {
+	if publicID == "" || publicID != filepath.Base(publicID) || strings.HasPrefix(publicID, ".") {
+		return "", fmt.Errorf("invalid public id : %q", publicID)
+	}
+
+	return filepath.Join(s.Root, publicID), nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+type S3Storage struct {
+	Client    *minio.Client
+	Bucket    string
+	PublicURL string        // When set, links are built from this address instead of being presigned
+	URLExpiry time.Duration // Lifetime of presigned links
+}
+
+func NewS3Storage(client *minio.Client, bucket string, publicURL string, urlExpiry time.Duration) *S3Storage {
+	return &S3Storage{
+		Client:    client,
+		Bucket:    bucket,
+		PublicURL: strings.TrimRight(publicURL, "/"),
+		URLExpiry: urlExpiry,
+	}
+}
+
+func (s *S3Storage) Put(ctx context.Context, publicID string, content io.Reader, contentType string) (string, error) {
+	// Pass the content size when it is known, so the object is not uploaded in multipart
+	size := int64(-1)
+	if sized, ok := content.(interface{ Len() int }); ok {
+		size = int64(sized.Len())
+	}
+
+	if _, err := s.Client.PutObject(ctx, s.Bucket, publicID, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	}); err != nil {
+		return "", err
+	}
+
+	return s.URL(ctx, publicID)
+}
+
+func (s *S3Storage) Get(ctx context.Context, publicID string) (io.ReadCloser, error) {
+	object, err := s.Client.GetObject(ctx, s.Bucket, publicID, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, stat the object so a missing key is reported here
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, publicID string) error {
+	return s.Client.RemoveObject(ctx, s.Bucket, publicID, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(ctx context.Context, publicID string) (string, error) {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + publicID, nil
+	}
+
+	presignedURL, err := s.Client.PresignedGetObject(ctx, s.Bucket, publicID, s.URLExpiry, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return presignedURL.String(), nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// Storage is the backend where original and processed images are kept
+type Storage interface {
+	// Put stores the content under the given public ID and returns its public URL
+	Put(ctx context.Context, publicID string, content io.Reader, contentType string) (string, error)
+	// Get opens the content stored under the given public ID
+	Get(ctx context.Context, publicID string) (io.ReadCloser, error)
+	// Delete removes the content stored under the given public ID
+	Delete(ctx context.Context, publicID string) error
+	// URL returns the public URL of the content stored under the given public ID
+	URL(ctx context.Context, publicID string) (string, error)
+}
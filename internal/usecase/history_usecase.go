@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-image-api/internal/entity"
+	"go-image-api/internal/model"
+	"go-image-api/internal/model/converter"
+	"go-image-api/internal/repository"
+	"go-image-api/internal/storage"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type HistoryUseCase struct {
+	DB                *gorm.DB
+	Validate          *validator.Validate
+	Log               *logrus.Logger
+	Storage           storage.Storage
+	HistoryRepository *repository.HistoryRepository
+}
+
+func NewHistoryUseCase(db *gorm.DB, validate *validator.Validate, log *logrus.Logger, store storage.Storage,
+	historyRepository *repository.HistoryRepository) *HistoryUseCase {
+	return &HistoryUseCase{
+		DB:                db,
+		Validate:          validate,
+		Log:               log,
+		Storage:           store,
+		HistoryRepository: historyRepository,
+	}
+}
+
+func (u *HistoryUseCase) Search(ctx context.Context, request *model.SearchHistoryRequest) (*model.PageResponse[model.History], error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Search histories
+	histories, total, err := u.HistoryRepository.Search(tx, request)
+	if err != nil {
+		u.Log.Warnf("Failed to search histories : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := &model.PageResponse[model.History]{
+		Data: make([]model.History, len(histories)),
+		Paging: model.PageMetadata{
+			Page:      request.Page,
+			Size:      request.Size,
+			TotalItem: total,
+			TotalPage: (total + int64(request.Size) - 1) / int64(request.Size),
+		},
+	}
+	for i, history := range histories {
+		response.Data[i] = *converter.HistoryToResponse(&history)
+	}
+	return response, nil
+}
+
+func (u *HistoryUseCase) Get(ctx context.Context, request *model.GetHistoryRequest) (*model.History, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Find history
+	history := new(entity.History)
+	if err := u.HistoryRepository.FindByID(tx, history, request.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "history is not found")
+		}
+		u.Log.Warnf("Failed to find history : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.HistoryToResponse(history), nil
+}
+
+func (u *HistoryUseCase) Delete(ctx context.Context, request *model.DeleteHistoryRequest) error {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return err
+	}
+
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Find history
+	history := new(entity.History)
+	if err := u.HistoryRepository.FindByID(tx, history, request.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "history is not found")
+		}
+		u.Log.Warnf("Failed to find history : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	// Remove both images and the watermark logos from storage, the history is kept if it fails so the deletion
+	// can be retried. Histories created before public IDs were recorded have nothing to remove.
+	publicIDs := append([]string{history.ImagePublicIDBefore, history.ImagePublicIDAfter}, history.WatermarkPublicIDs...)
+	for _, publicID := range publicIDs {
+		if publicID == "" {
+			continue
+		}
+		if err := u.Storage.Delete(ctx, publicID); err != nil {
+			u.Log.Warnf("Failed to delete image %s from storage : %+v", publicID, err)
+			return fiber.ErrInternalServerError
+		}
+	}
+
+	// Delete history
+	if err := u.HistoryRepository.Delete(tx, history); err != nil {
+		u.Log.Warnf("Failed to delete history : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
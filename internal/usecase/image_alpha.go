@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"image"
+	"image/color"
+
+	"gocv.io/x/gocv"
+)
+
+// Content types which can not keep the alpha channel, transparent images are flattened before encoded into them
+var opaqueContentTypes = []string{
+	"image/jpg",
+	"image/jpeg",
+	"image/gif",
+}
+
+// Composites BGRA Mat onto an opaque background color, returning BGR Mat
+func flattenMat(src gocv.Mat, background color.RGBA) gocv.Mat {
+	channels := gocv.Split(src)
+	defer func() {
+		for _, channel := range channels {
+			channel.Close()
+		}
+	}()
+
+	// Work in float, so the blending does not saturate in between: dst = background + (src - background) * alpha
+	foreground := gocv.NewMat()
+	defer foreground.Close()
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	gocv.Merge(channels[:3], &bgr)
+	bgr.ConvertTo(&foreground, gocv.MatTypeCV32FC3)
+
+	alpha := gocv.NewMat()
+	defer alpha.Close()
+	channels[3].ConvertToWithParams(&alpha, gocv.MatTypeCV32F, 1.0/255, 0)
+	alpha3 := gocv.NewMat()
+	defer alpha3.Close()
+	gocv.Merge([]gocv.Mat{alpha, alpha, alpha}, &alpha3)
+
+	backgroundMat := gocv.NewMatWithSizeFromScalar(
+		gocv.NewScalar(float64(background.B), float64(background.G), float64(background.R), 0),
+		src.Rows(), src.Cols(), gocv.MatTypeCV32FC3)
+	defer backgroundMat.Close()
+
+	blended := gocv.NewMat()
+	defer blended.Close()
+	gocv.Subtract(foreground, backgroundMat, &blended)
+	gocv.Multiply(blended, alpha3, &blended)
+	gocv.Add(blended, backgroundMat, &blended)
+
+	dst := gocv.NewMat()
+	blended.ConvertTo(&dst, gocv.MatTypeCV8UC3)
+
+	return dst
+}
+
+// Returns the smallest box containing every pixel which is not fully transparent,
+// false is returned when the Mat has no alpha channel or is fully transparent
+func opaqueBounds(src gocv.Mat) (image.Rectangle, bool) {
+	if src.Channels() != 4 {
+		return image.Rectangle{}, false
+	}
+
+	channels := gocv.Split(src)
+	defer func() {
+		for _, channel := range channels {
+			channel.Close()
+		}
+	}()
+
+	// Maximum alpha of every column and every row, a non zero maximum means it has visible pixels
+	columns := gocv.NewMat()
+	defer columns.Close()
+	gocv.Reduce(channels[3], &columns, 0, gocv.ReduceMax, gocv.MatTypeCV8U)
+	rows := gocv.NewMat()
+	defer rows.Close()
+	gocv.Reduce(channels[3], &rows, 1, gocv.ReduceMax, gocv.MatTypeCV8U)
+
+	minX, maxX := -1, -1
+	for x := 0; x < columns.Cols(); x++ {
+		if columns.GetUCharAt(0, x) > 0 {
+			if minX < 0 {
+				minX = x
+			}
+			maxX = x
+		}
+	}
+	minY, maxY := -1, -1
+	for y := 0; y < rows.Rows(); y++ {
+		if rows.GetUCharAt(y, 0) > 0 {
+			if minY < 0 {
+				minY = y
+			}
+			maxY = y
+		}
+	}
+	if minX < 0 || minY < 0 {
+		return image.Rectangle{}, false
+	}
+
+	return image.Rect(minX, minY, maxX+1, maxY+1), true
+}
+
+// Trims fully transparent borders, the Mat is kept as is when there is nothing to trim
+func trimTransparentMat(src gocv.Mat) gocv.Mat {
+	bounds, ok := opaqueBounds(src)
+	if !ok || bounds == image.Rect(0, 0, src.Cols(), src.Rows()) {
+		return src.Clone()
+	}
+
+	region := src.Region(bounds)
+	defer region.Close()
+
+	return region.Clone()
+}
+
+// Blends BGRA overlay onto the Mat in place with its top left corner at the origin, the overlay alpha is
+// scaled by opacity (0-1). The overlay is clipped to the Mat, which should have 3 or 4 channels
+func blendOverlay(dst *gocv.Mat, overlay gocv.Mat, origin image.Point, opacity float64) {
+	box := image.Rectangle{Min: origin, Max: origin.Add(image.Point{X: overlay.Cols(), Y: overlay.Rows()})}
+	rect := box.Intersect(image.Rect(0, 0, dst.Cols(), dst.Rows()))
+	if rect.Empty() {
+		return
+	}
+
+	// Region shares the memory of the Mat, so the blended pixels are copied back into it
+	region := dst.Region(rect)
+	defer region.Close()
+	visible := overlay.Region(rect.Sub(origin))
+	defer visible.Close()
+
+	overlayChannels := gocv.Split(visible)
+	regionChannels := gocv.Split(region)
+	defer func() {
+		for _, channel := range append(overlayChannels, regionChannels...) {
+			channel.Close()
+		}
+	}()
+
+	// Work in float as in flattenMat: dst = region + (overlay - region) * alpha
+	alpha := gocv.NewMat()
+	defer alpha.Close()
+	overlayChannels[3].ConvertToWithParams(&alpha, gocv.MatTypeCV32F, float32(opacity/255), 0)
+	alpha3 := gocv.NewMat()
+	defer alpha3.Close()
+	gocv.Merge([]gocv.Mat{alpha, alpha, alpha}, &alpha3)
+
+	toFloat := func(channels []gocv.Mat) gocv.Mat {
+		merged := gocv.NewMat()
+		defer merged.Close()
+		gocv.Merge(channels[:3], &merged)
+		dst := gocv.NewMat()
+		merged.ConvertTo(&dst, gocv.MatTypeCV32FC3)
+		return dst
+	}
+	foreground, background := toFloat(overlayChannels), toFloat(regionChannels)
+	defer foreground.Close()
+	defer background.Close()
+
+	blended := gocv.NewMat()
+	defer blended.Close()
+	gocv.Subtract(foreground, background, &blended)
+	gocv.Multiply(blended, alpha3, &blended)
+	gocv.Add(blended, background, &blended)
+
+	result := gocv.NewMat()
+	defer result.Close()
+	blended.ConvertTo(&result, gocv.MatTypeCV8UC3)
+	if dst.Channels() == 4 {
+		// Composite alpha too: dst alpha + (255 - dst alpha) * alpha
+		regionAlpha := gocv.NewMat()
+		defer regionAlpha.Close()
+		regionChannels[3].ConvertTo(&regionAlpha, gocv.MatTypeCV32F)
+		uncovered := gocv.NewMat()
+		defer uncovered.Close()
+		regionAlpha.ConvertToWithParams(&uncovered, gocv.MatTypeCV32F, -1, 255)
+		gocv.Multiply(uncovered, alpha, &uncovered)
+		gocv.Add(regionAlpha, uncovered, &regionAlpha)
+
+		resultAlpha := gocv.NewMat()
+		defer resultAlpha.Close()
+		regionAlpha.ConvertTo(&resultAlpha, gocv.MatTypeCV8U)
+		resultChannels := gocv.Split(result)
+		defer func() {
+			for _, channel := range resultChannels {
+				channel.Close()
+			}
+		}()
+		gocv.Merge(append(resultChannels, resultAlpha), &result)
+	}
+
+	result.CopyTo(&region)
+}
+
+// Separates the color channels from the alpha channel, so filters do not alter transparency.
+// The returned alpha is empty when the Mat has no alpha channel
+func splitAlpha(src gocv.Mat) (gocv.Mat, gocv.Mat) {
+	if src.Channels() != 4 {
+		return src.Clone(), gocv.NewMat()
+	}
+
+	channels := gocv.Split(src)
+	defer func() {
+		for _, channel := range channels[:3] {
+			channel.Close()
+		}
+	}()
+	colors := gocv.NewMat()
+	gocv.Merge(channels[:3], &colors)
+
+	return colors, channels[3]
+}
+
+// Puts the alpha channel back onto the color channels, grayscale colors are expanded into BGR.
+// The color Mat is consumed, either returned as is or closed
+func mergeAlpha(colors gocv.Mat, alpha gocv.Mat) gocv.Mat {
+	if alpha.Empty() {
+		return colors
+	}
+	defer colors.Close()
+
+	if colors.Channels() == 1 {
+		gocv.CvtColor(colors, &colors, gocv.ColorGrayToBGR)
+	}
+	channels := gocv.Split(colors)
+	defer func() {
+		for _, channel := range channels {
+			channel.Close()
+		}
+	}()
+	dst := gocv.NewMat()
+	gocv.Merge(append(channels, alpha), &dst)
+
+	return dst
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"mime/multipart"
+	"runtime"
+	"sync"
+)
+
+func (u *ImageUseCase) BatchConvertPNGToJPEG(ctx context.Context, requests []*model.ImageRequest) *model.BatchResponse {
+	fileHeaders := make([]*multipart.FileHeader, len(requests))
+	for i, request := range requests {
+		fileHeaders[i] = request.ImageFileHeader
+	}
+
+	return u.batch(ctx, fileHeaders, func(ctx context.Context, i int) (*model.ImageResponse, error) {
+		return u.ConvertPNGToJPEG(ctx, requests[i])
+	})
+}
+
+func (u *ImageUseCase) BatchResizeImage(ctx context.Context, requests []*model.ImageResizeRequest) *model.BatchResponse {
+	fileHeaders := make([]*multipart.FileHeader, len(requests))
+	for i, request := range requests {
+		fileHeaders[i] = request.ImageFileHeader
+	}
+
+	return u.batch(ctx, fileHeaders, func(ctx context.Context, i int) (*model.ImageResponse, error) {
+		return u.ResizeImage(ctx, requests[i])
+	})
+}
+
+func (u *ImageUseCase) BatchCompressImage(ctx context.Context, requests []*model.ImageCompressRequest) *model.BatchResponse {
+	fileHeaders := make([]*multipart.FileHeader, len(requests))
+	for i, request := range requests {
+		fileHeaders[i] = request.ImageFileHeader
+	}
+
+	return u.batch(ctx, fileHeaders, func(ctx context.Context, i int) (*model.ImageResponse, error) {
+		return u.CompressImage(ctx, requests[i])
+	})
+}
+
+// Processes every file concurrently with bounded number of workers, results are kept in upload order
+func (u *ImageUseCase) batch(ctx context.Context, fileHeaders []*multipart.FileHeader,
+	process func(context.Context, int) (*model.ImageResponse, error)) *model.BatchResponse {
+	// If worker count is not configured, set defaults to number of CPU
+	workerCount := u.ViperConfig.GetInt("BATCH_WORKER_COUNT")
+	if workerCount < 1 {
+		workerCount = runtime.NumCPU()
+	}
+	workerCount = min(workerCount, len(fileHeaders))
+
+	response := &model.BatchResponse{
+		Data: make([]model.BatchItemResponse, len(fileHeaders)),
+	}
+
+	indexes := make(chan int)
+	wg := new(sync.WaitGroup)
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				item := &response.Data[i]
+				item.FileName = fileHeaders[i].Filename
+
+				result, err := process(ctx, i)
+				if err != nil {
+					item.Error = helper.NewErrorResponse(err)
+					continue
+				}
+				item.Result = result
+			}
+		}()
+	}
+
+	for i := range fileHeaders {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return response
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+func (u *ImageUseCase) ConvertImage(ctx context.Context, request *model.ImageConvertRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.convertImage(ctx, &request.ConvertParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) convertImage(ctx context.Context, params *model.ConvertParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	return u.convert(ctx, "convert_image", params, "image/"+params.TargetFormat, originalImageBytes, "original_", "converted_")
+}
+
+// Kept for compatibility, converts png into jpeg and records it as convert_png_jpeg
+func (u *ImageUseCase) ConvertPNGToJPEG(ctx context.Context, request *model.ImageRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &model.ConvertParams{
+		TargetFormat: "jpeg",
+		AlphaParams:  request.AlphaParams,
+		JPEGParams:   request.JPEGParams,
+		OrientParams: request.OrientParams,
+	}
+	return u.convertPNGToJPEG(ctx, params, originalImageBytes)
+}
+
+// Target format of the params is always set to jpeg
+func (u *ImageUseCase) convertPNGToJPEG(ctx context.Context, params *model.ConvertParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png
+	if http.DetectContentType(originalImageBytes) != "image/png" {
+		u.Log.Warn("Validation error : file is not in png")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png")
+	}
+
+	// Result is recorded as image/jpg as it always has been, so histories of this type stay in one extension
+	params.TargetFormat = "jpeg"
+	return u.convert(ctx, "convert_png_jpeg", params, "image/jpg", originalImageBytes, "png_", "jpeg_")
+}
+
+// Decodes image in any supported format and encodes it into the target content type of the target format
+func (u *ImageUseCase) convert(ctx context.Context, historyType string, params *model.ConvertParams, targetContentType string,
+	originalImageBytes []byte, originalIDPrefix string, resultIDPrefix string) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpeg, webp, bmp, tiff, or gif
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+		"image/webp",
+		"image/bmp",
+		"image/tiff",
+		"image/gif",
+	}
+	contentType := detectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpeg, webp, bmp, tiff, or gif")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpeg, webp, bmp, tiff, or gif")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Handle transparency, then convert Mat into bytes in the target format
+	newMat, err := u.convertMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+	newImageBytes, err := u.encodeMatWithParams(newMat, targetContentType, 0, &params.JPEGParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	converted := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: targetContentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, historyType, original, originalIDPrefix, converted, resultIDPrefix, params)
+}
+
+// Trims transparent borders when asked to, then flattens transparency onto the background color
+// when the target format can not keep it
+func (u *ImageUseCase) convertMat(src gocv.Mat, params *model.ConvertParams) (gocv.Mat, error) {
+	// If 'background_color' is empty, set default as white
+	backgroundColor := params.BackgroundColor
+	if backgroundColor == "" {
+		backgroundColor = "#ffffff"
+	}
+	background, err := helper.ParseHexColor(backgroundColor)
+	if err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'background_color' should be a hex color")
+	}
+
+	dst := src.Clone()
+	if params.TrimTransparent {
+		trimmed := trimTransparentMat(dst)
+		dst.Close()
+		dst = trimmed
+	}
+	if dst.Channels() == 4 && slices.Contains(opaqueContentTypes, "image/"+params.TargetFormat) {
+		flattened := flattenMat(dst, background)
+		dst.Close()
+		dst = flattened
+	}
+
+	return dst, nil
+}
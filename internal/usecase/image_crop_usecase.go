@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-image-api/internal/model"
+	"image"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+func (u *ImageUseCase) CropImage(ctx context.Context, request *model.ImageCropRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.cropImage(ctx, &request.CropParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) cropImage(ctx context.Context, params *model.CropParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Perform cropping, the box is validated against the decoded dimension
+	newMat, err := u.cropMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	cropped := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, "crop_image", original, "original_", cropped, "cropped_", params)
+}
+
+func (u *ImageUseCase) cropStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.CropParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.cropMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Copies the requested box out of the Mat
+func (u *ImageUseCase) cropMat(src gocv.Mat, params *model.CropParams) (gocv.Mat, error) {
+	rect, err := u.cropRect(src, params)
+	if err != nil {
+		return gocv.NewMat(), err
+	}
+
+	// Region shares the memory of the source, so it is cloned to outlive it
+	region := src.Region(rect)
+	defer region.Close()
+
+	return region.Clone(), nil
+}
+
+// Resolves the crop box of the given mode within the Mat
+func (u *ImageUseCase) cropRect(src gocv.Mat, params *model.CropParams) (image.Rectangle, error) {
+	width, height := src.Cols(), src.Rows()
+	var rect image.Rectangle
+	switch params.Mode {
+	case "pixel":
+		if params.WidthInPixels < 1 || params.HeightInPixels < 1 {
+			u.Log.Warn("Validation error : crop width or height is empty")
+			return rect, fiber.NewError(fiber.StatusBadRequest, "'width_in_pixels' and 'height_in_pixels' are required")
+		}
+		rect = image.Rect(params.X, params.Y, params.X+params.WidthInPixels, params.Y+params.HeightInPixels)
+	case "percent":
+		if params.WidthPercent <= 0 || params.HeightPercent <= 0 {
+			u.Log.Warn("Validation error : crop width or height percentage is empty")
+			return rect, fiber.NewError(fiber.StatusBadRequest, "'width_percent' and 'height_percent' are required")
+		}
+		x := percentOf(width, params.XPercent)
+		y := percentOf(height, params.YPercent)
+		rect = image.Rect(x, y, x+percentOf(width, params.WidthPercent), y+percentOf(height, params.HeightPercent))
+	case "gravity":
+		// Size is taken in pixels, or in percentage of the image when pixels are not given
+		cropWidth, cropHeight := params.WidthInPixels, params.HeightInPixels
+		if cropWidth < 1 {
+			cropWidth = percentOf(width, params.WidthPercent)
+		}
+		if cropHeight < 1 {
+			cropHeight = percentOf(height, params.HeightPercent)
+		}
+		if cropWidth < 1 || cropHeight < 1 {
+			u.Log.Warn("Validation error : crop width or height is empty")
+			return rect, fiber.NewError(fiber.StatusBadRequest,
+				"'width_in_pixels' and 'height_in_pixels', or 'width_percent' and 'height_percent' are required")
+		}
+		origin := u.placeBox(src, params.Gravity, cropWidth, cropHeight)
+		rect = image.Rect(origin.X, origin.Y, origin.X+cropWidth, origin.Y+cropHeight)
+	}
+
+	// Validate the box is not empty and lies within the image
+	if rect.Empty() || !rect.In(image.Rect(0, 0, width, height)) {
+		u.Log.Warnf("Validation error : crop box %v is out of %dx%d image", rect, width, height)
+		return rect, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("crop box should be within the image of %dx%d pixels", width, height))
+	}
+
+	return rect, nil
+}
+
+// Returns the top left point to place a box of the given size inside the area, anchored by gravity.
+// Empty gravity is considered as center
+func gravityOrigin(gravity string, width, height, boxWidth, boxHeight int) image.Point {
+	origin := image.Point{X: (width - boxWidth) / 2, Y: (height - boxHeight) / 2}
+	switch gravity {
+	case "north", "north-west", "north-east":
+		origin.Y = 0
+	case "south", "south-west", "south-east":
+		origin.Y = height - boxHeight
+	}
+	switch gravity {
+	case "west", "north-west", "south-west":
+		origin.X = 0
+	case "east", "north-east", "south-east":
+		origin.X = width - boxWidth
+	}
+
+	return origin
+}
+
+// Returns the given percentage of a length, rounded to the nearest pixel
+func percentOf(length int, percent float64) int {
+	return int(math.Round(float64(length) * percent / 100))
+}
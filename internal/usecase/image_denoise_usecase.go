@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-image-api/internal/model"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+func (u *ImageUseCase) DenoiseImage(ctx context.Context, request *model.ImageDenoiseRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.denoiseImage(ctx, &request.DenoiseParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) denoiseImage(ctx context.Context, params *model.DenoiseParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Perform denoising
+	newMat, err := u.denoiseMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	denoised := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, "denoise_image", original, "original_", denoised, "denoised_", params)
+}
+
+func (u *ImageUseCase) denoiseStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.DenoiseParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.denoiseMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Removes noise by non-local means, comparing every patch of template window size with patches
+// within the search window. Larger strength removes more noise along with more details
+func (u *ImageUseCase) denoiseMat(src gocv.Mat, params *model.DenoiseParams) (gocv.Mat, error) {
+	// If 'strength' is empty, set default as 10, and 'color_strength' as the strength
+	strength := params.Strength
+	if strength <= 0 {
+		strength = 10
+	}
+	colorStrength := params.ColorStrength
+	if colorStrength <= 0 {
+		colorStrength = strength
+	}
+
+	// If 'template_window_size' or 'search_window_size' is empty, set default as OpenCV, 7 and 21
+	templateWindowSize := params.TemplateWindowSize
+	if templateWindowSize < 1 {
+		templateWindowSize = 7
+	}
+	searchWindowSize := params.SearchWindowSize
+	if searchWindowSize < 1 {
+		searchWindowSize = 21
+	}
+	// Validate window sizes are odd, so the windows are centered on the pixel
+	windows := []struct {
+		name string
+		size int
+	}{{"template_window_size", templateWindowSize}, {"search_window_size", searchWindowSize}}
+	for _, window := range windows {
+		if window.size%2 == 0 {
+			u.Log.Warnf("Validation error : %s %d is even", window.name, window.size)
+			return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("'%s' should be odd", window.name))
+		}
+	}
+
+	colors, alpha := splitAlpha(src)
+	defer alpha.Close()
+	defer colors.Close()
+
+	denoised := gocv.NewMat()
+	if colors.Channels() == 1 {
+		gocv.FastNlMeansDenoisingWithParams(colors, &denoised, float32(strength), templateWindowSize, searchWindowSize)
+	} else {
+		gocv.FastNlMeansDenoisingColoredWithParams(colors, &denoised, float32(strength), float32(colorStrength),
+			templateWindowSize, searchWindowSize)
+	}
+
+	return mergeAlpha(denoised, alpha), nil
+}
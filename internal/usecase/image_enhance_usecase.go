@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-image-api/internal/model"
+	"image"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+func (u *ImageUseCase) EnhanceImage(ctx context.Context, request *model.ImageEnhanceRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.enhanceImage(ctx, &request.EnhanceParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) enhanceImage(ctx context.Context, params *model.EnhanceParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Perform enhancement
+	newMat, err := u.enhanceMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	enhanced := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, "enhance_image", original, "original_", enhanced, "enhanced_", params)
+}
+
+func (u *ImageUseCase) enhanceStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.EnhanceParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.enhanceMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Color conversions into and out of the spaces whose first channel is luminance
+var luminanceSpaces = map[string][2]gocv.ColorConversionCode{
+	"lab":   {gocv.ColorBGRToLab, gocv.ColorLabToBGR},
+	"ycrcb": {gocv.ColorBGRToYCrCb, gocv.ColorYCrCbToBGR},
+}
+
+// Spreads the luminance histogram, globally or by CLAHE, leaving the chroma and alpha channels as is
+func (u *ImageUseCase) enhanceMat(src gocv.Mat, params *model.EnhanceParams) (gocv.Mat, error) {
+	// If 'method' is empty, set default as clahe
+	method := params.Method
+	if method == "" {
+		method = "clahe"
+	}
+
+	// If 'color_space' is empty, set default as lab
+	colorSpace := params.ColorSpace
+	if colorSpace == "" {
+		colorSpace = "lab"
+	}
+
+	// If 'clip_limit' or 'tile_grid_size' is empty, set default as OpenCV, 2 and 8
+	clipLimit := params.ClipLimit
+	if clipLimit <= 0 {
+		clipLimit = 2
+	}
+	tileGridSize := params.TileGridSize
+	if tileGridSize < 1 {
+		tileGridSize = 8
+	}
+
+	equalize := func(src gocv.Mat, dst *gocv.Mat) {
+		if method == "equalize" {
+			gocv.EqualizeHist(src, dst)
+			return
+		}
+		clahe := gocv.NewCLAHEWithParams(clipLimit, image.Point{X: tileGridSize, Y: tileGridSize})
+		defer clahe.Close()
+		clahe.Apply(src, dst)
+	}
+
+	colors, alpha := splitAlpha(src)
+	defer alpha.Close()
+	defer func() { colors.Close() }()
+
+	// Grayscale is its own luminance
+	if colors.Channels() == 1 {
+		enhanced := gocv.NewMat()
+		equalize(colors, &enhanced)
+		return mergeAlpha(enhanced, alpha), nil
+	}
+
+	conversions := luminanceSpaces[colorSpace]
+	converted := gocv.NewMat()
+	defer converted.Close()
+	gocv.CvtColor(colors, &converted, conversions[0])
+	channels := gocv.Split(converted)
+	defer func() {
+		for _, channel := range channels {
+			channel.Close()
+		}
+	}()
+
+	luminance := gocv.NewMat()
+	defer luminance.Close()
+	equalize(channels[0], &luminance)
+	gocv.Merge([]gocv.Mat{luminance, channels[1], channels[2]}, &converted)
+
+	enhanced := gocv.NewMat()
+	gocv.CvtColor(converted, &enhanced, conversions[1])
+	return mergeAlpha(enhanced, alpha), nil
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-image-api/internal/model"
+	"image"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+// Sigma of the blur subtracted by the unsharp mask
+const sharpenSigma = 1.5
+
+func (u *ImageUseCase) FilterImage(ctx context.Context, request *model.ImageFilterRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.filterImage(ctx, &request.FilterParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) filterImage(ctx context.Context, params *model.FilterParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Apply filters
+	newMat, err := u.filterMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	filtered := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, "filter_image", original, "original_", filtered, "filtered_", params)
+}
+
+func (u *ImageUseCase) filterStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.FilterParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.filterMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Applies the requested filters on the color channels, alpha channel is kept as is
+func (u *ImageUseCase) filterMat(src gocv.Mat, params *model.FilterParams) (gocv.Mat, error) {
+	if !params.Grayscale && params.Brightness == 0 && params.Contrast == 0 && params.Gamma == 0 &&
+		params.Blur == 0 && params.Sharpen == 0 {
+		u.Log.Warn("Validation error : no filter is given")
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest,
+			"at least one of 'grayscale', 'brightness', 'contrast', 'gamma', 'blur', or 'sharpen' is required")
+	}
+
+	colors, alpha := splitAlpha(src)
+	defer alpha.Close()
+
+	// Every filter replaces the Mat, closing the previous one
+	apply := func(filter func(src gocv.Mat, dst *gocv.Mat)) {
+		dst := gocv.NewMat()
+		filter(colors, &dst)
+		colors.Close()
+		colors = dst
+	}
+
+	if params.Grayscale && colors.Channels() == 3 {
+		apply(func(src gocv.Mat, dst *gocv.Mat) {
+			gocv.CvtColor(src, dst, gocv.ColorBGRToGray)
+		})
+	}
+	if params.Brightness != 0 || params.Contrast != 0 {
+		// If 'contrast' is empty, set default as 1.
+		// ConvertScaleAbs is not used, as it would mirror negative values instead of saturating them into 0
+		contrast := params.Contrast
+		if params.Contrast == 0 {
+			contrast = 1
+		}
+		apply(func(src gocv.Mat, dst *gocv.Mat) {
+			src.ConvertToWithParams(dst, src.Type(), float32(contrast), float32(params.Brightness))
+		})
+	}
+	if params.Gamma > 0 {
+		lut := gammaLUT(params.Gamma)
+		defer lut.Close()
+		apply(func(src gocv.Mat, dst *gocv.Mat) {
+			gocv.LUT(src, lut, dst)
+		})
+	}
+	if params.Blur > 0 {
+		apply(func(src gocv.Mat, dst *gocv.Mat) {
+			gocv.GaussianBlur(src, dst, image.Point{}, params.Blur, params.Blur, gocv.BorderDefault)
+		})
+	}
+	if params.Sharpen > 0 {
+		// Unsharp mask: src + (src - blurred) * amount
+		apply(func(src gocv.Mat, dst *gocv.Mat) {
+			blurred := gocv.NewMat()
+			defer blurred.Close()
+			gocv.GaussianBlur(src, &blurred, image.Point{}, sharpenSigma, sharpenSigma, gocv.BorderDefault)
+			gocv.AddWeighted(src, 1+params.Sharpen, blurred, -params.Sharpen, 0, dst)
+		})
+	}
+
+	return mergeAlpha(colors, alpha), nil
+}
+
+// Lookup table of gamma correction, gamma above 1 brightens the midtones
+func gammaLUT(gamma float64) gocv.Mat {
+	lut := gocv.NewMatWithSize(1, 256, gocv.MatTypeCV8U)
+	for i := 0; i < 256; i++ {
+		lut.SetUCharAt(0, i, uint8(math.Round(255*math.Pow(float64(i)/255, 1/gamma))))
+	}
+
+	return lut
+}
@@ -0,0 +1,554 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-image-api/internal/entity"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gocv.io/x/gocv"
+)
+
+// Encoded image along with its detected content type and dimension
+type encodedImage struct {
+	Bytes        []byte
+	ContentType  string
+	Width        int
+	Height       int
+	Quality      int      // Encoder quality, only set for compressed result
+	SSIM         *float64 // Quality metrics against the original, only set when measured
+	PSNR         *float64
+	WatermarkIDs []string // Public IDs of the stored watermark logos, only set for watermarked result
+}
+
+// Reads uploaded file into bytes
+func (u *ImageUseCase) readImage(fileHeader *multipart.FileHeader) ([]byte, error) {
+	// Open file header
+	imageFile, err := fileHeader.Open()
+	if err != nil {
+		u.Log.Warnf("Failed to open file content : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	defer imageFile.Close()
+
+	// Convert file to buffer
+	fileBuff, err := helper.FormFileToBuffer(u.Log, imageFile)
+	if err != nil {
+		u.Log.Warnf("Failed to open file content : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return fileBuff.Bytes(), nil
+}
+
+// Content types decoded and encoded by OpenCV along with their file extension, gif is handled by stdlib
+var matFileExts = map[string]gocv.FileExt{
+	"image/png":  gocv.PNGFileExt,
+	"image/jpg":  gocv.JPEGFileExt,
+	"image/jpeg": gocv.JPEGFileExt,
+	"image/webp": gocv.FileExt(".webp"),
+	"image/bmp":  gocv.FileExt(".bmp"),
+	"image/tiff": gocv.FileExt(".tiff"),
+}
+
+// Detects content type of image bytes, recognizing tiff which is not sniffed by http.DetectContentType
+func detectContentType(imageBytes []byte) string {
+	if bytes.HasPrefix(imageBytes, []byte("II*\x00")) || bytes.HasPrefix(imageBytes, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+
+	return http.DetectContentType(imageBytes)
+}
+
+// Decodes image bytes into Mat, applying EXIF orientation when asked to
+func (u *ImageUseCase) decodeMat(imageBytes []byte, autoOrient bool) (gocv.Mat, error) {
+	// OpenCV can not read gif, so only its first frame is decoded by stdlib
+	if http.DetectContentType(imageBytes) == "image/gif" {
+		return u.decodeGIF(imageBytes)
+	}
+
+	// Unchanged keeps the alpha channel, which any other flag drops. It also ignores EXIF orientation,
+	// which is applied explicitly below only when asked to, so 'auto_orient' decides the result
+	mat, err := gocv.IMDecode(imageBytes, gocv.IMReadUnchanged)
+	if err != nil {
+		u.Log.Warnf("Failed to convert image to Mat : %+v", err)
+		return mat, fiber.ErrInternalServerError
+	}
+	if mat.Empty() {
+		mat.Close()
+		u.Log.Warn("Validation error : file could not be decoded")
+		return mat, fiber.NewError(fiber.StatusBadRequest, "file is not a valid image")
+	}
+	toDepth8U(&mat)
+	if autoOrient {
+		orientMat(&mat, helper.ExifOrientation(imageBytes))
+	}
+
+	return mat, nil
+}
+
+// Converts 16 bits and float Mat in place into 8 bits, as unchanged decoding keeps the depth of png and tiff
+// while every operation and encoder here works on 8 bits
+func toDepth8U(mat *gocv.Mat) {
+	var scale float32
+	switch mat.Type() & 7 {
+	case gocv.MatTypeCV8U:
+		return
+	case gocv.MatTypeCV16U:
+		scale = 1.0 / 257
+	case gocv.MatTypeCV32F, gocv.MatTypeCV64F:
+		scale = 255
+	default:
+		scale = 1
+	}
+
+	// Only the depth of the type is taken, channels are kept
+	converted := gocv.NewMat()
+	mat.ConvertToWithParams(&converted, gocv.MatTypeCV8U, scale, 0)
+	mat.Close()
+	*mat = converted
+}
+
+// Decodes the first frame of gif into BGRA Mat, keeping its transparency
+func (u *ImageUseCase) decodeGIF(imageBytes []byte) (gocv.Mat, error) {
+	frame, err := gif.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		u.Log.Warnf("Validation error : gif could not be decoded : %+v", err)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "file is not a valid image")
+	}
+
+	// Paletted image is drawn into NRGBA first, OpenCV has no paletted Mat
+	bounds := frame.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), frame, bounds.Min, draw.Src)
+
+	mat, err := gocv.ImageToMatRGBA(nrgba)
+	if err != nil {
+		u.Log.Warnf("Failed to convert gif to Mat : %+v", err)
+		return mat, fiber.ErrInternalServerError
+	}
+
+	return mat, nil
+}
+
+// Encodes Mat into the given content type, quality is only applied to jpeg and webp and ignored when zero
+func (u *ImageUseCase) encodeMat(mat gocv.Mat, contentType string, quality int) ([]byte, error) {
+	return u.encodeMatWithParams(mat, contentType, quality, nil)
+}
+
+// OpenCV 4.7 IMWRITE_JPEG_SAMPLING_FACTOR, not exposed by gocv yet
+const imWriteJpegSamplingFactor = 7
+
+// Sampling factors of the luma component by chroma subsampling, chroma components are always 1x1
+var jpegSamplingFactors = map[string]int{
+	"420": 0x221111,
+	"422": 0x211111,
+	"444": 0x111111,
+}
+
+// Same as encodeMat, with the advanced encoder options applied when encoding into jpeg
+func (u *ImageUseCase) encodeMatWithParams(mat gocv.Mat, contentType string, quality int, jpeg *model.JPEGParams) ([]byte, error) {
+	if contentType == "image/gif" {
+		return u.encodeGIF(mat)
+	}
+
+	fileExt, ok := matFileExts[contentType]
+	if !ok {
+		u.Log.Warnf("Unsupported content type to encode : %s", contentType)
+		return nil, fiber.ErrInternalServerError
+	}
+	var params []int
+	if quality > 0 {
+		switch contentType {
+		case "image/jpg", "image/jpeg":
+			params = append(params, gocv.IMWriteJpegQuality, quality)
+		case "image/webp":
+			params = append(params, gocv.IMWriteWebpQuality, quality)
+		}
+	}
+	if jpeg != nil && (contentType == "image/jpg" || contentType == "image/jpeg") {
+		params = append(params, jpegEncodeParams(jpeg)...)
+	}
+
+	return u.encodeNative(fileExt, mat, params)
+}
+
+// Converts the advanced jpeg options into OpenCV encoder params, empty options are left to OpenCV defaults
+func jpegEncodeParams(jpeg *model.JPEGParams) []int {
+	var params []int
+	if jpeg.Progressive {
+		params = append(params, gocv.IMWriteJpegProgressive, 1)
+	}
+	if jpeg.Optimize {
+		params = append(params, gocv.IMWriteJpegOptimize, 1)
+	}
+	if jpeg.LumaQuality > 0 {
+		params = append(params, gocv.IMWriteJpegLumaQuality, jpeg.LumaQuality)
+	}
+	// OpenCV only applies chroma quality along with luma quality
+	if jpeg.ChromaQuality > 0 && jpeg.LumaQuality > 0 {
+		params = append(params, gocv.IMWriteJpegChromaQuality, jpeg.ChromaQuality)
+	}
+	if factor, ok := jpegSamplingFactors[jpeg.ChromaSubsampling]; ok {
+		params = append(params, imWriteJpegSamplingFactor, factor)
+	}
+	if jpeg.RestartInterval > 0 {
+		params = append(params, gocv.IMWriteJpegRstInterval, jpeg.RestartInterval)
+	}
+
+	return params
+}
+
+// Encodes Mat by OpenCV with the given encoder params
+func (u *ImageUseCase) encodeNative(fileExt gocv.FileExt, mat gocv.Mat, params []int) ([]byte, error) {
+	nativeBuff, err := gocv.IMEncodeWithParams(fileExt, mat, params)
+	if err != nil {
+		u.Log.Warnf("Failed to convert Mat to buffer : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	defer nativeBuff.Close()
+
+	// Copy out of the native buffer, it is freed once closed
+	return bytes.Clone(nativeBuff.GetBytes()), nil
+}
+
+// Encoder quality used when 'compress_quality' is not given, png is kept lossless instead
+const defaultCompressQuality = 70
+
+// Encodes Mat with the compress options of its content type
+func (u *ImageUseCase) compressMat(mat gocv.Mat, contentType string, params *model.CompressParams) ([]byte, error) {
+	if contentType == "image/png" {
+		return u.encodePNG(mat, params)
+	}
+
+	return u.encodeMatWithParams(mat, contentType, compressQuality(contentType, params), &params.JPEGParams)
+}
+
+// Returns the encoder quality of the content type, png only has one when it is given explicitly
+func compressQuality(contentType string, params *model.CompressParams) int {
+	if params.CompressQuality > 0 || contentType == "image/png" {
+		return params.CompressQuality
+	}
+
+	return defaultCompressQuality
+}
+
+// Returns the png palette size, zero means lossless. When it is not given, png is lossless unless quality is given,
+// then quality 90 and above is lossless and lower quality is mapped into up to 256 colors
+func pngColors(params *model.CompressParams) int {
+	if params.PNGColors > 0 {
+		return params.PNGColors
+	}
+	if params.CompressQuality == 0 || params.CompressQuality >= 90 {
+		return 0
+	}
+
+	return max(params.CompressQuality*256/90, 2)
+}
+
+// Encodes Mat into png, lossless by OpenCV or quantized into a palette by stdlib, as OpenCV can not write
+// paletted png. Neither of them writes metadata chunks
+func (u *ImageUseCase) encodePNG(mat gocv.Mat, params *model.CompressParams) ([]byte, error) {
+	// If 'png_compression_level' is empty, set default as 9
+	level := params.PNGCompressionLevel
+	if level < 1 {
+		level = 9
+	}
+
+	colors := pngColors(params)
+	if colors == 0 {
+		return u.encodeNative(gocv.PNGFileExt, mat, []int{gocv.IMWritePngCompression, level})
+	}
+
+	img, err := mat.ToImage()
+	if err != nil {
+		u.Log.Warnf("Failed to convert Mat to image : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, mat.Cols(), mat.Rows()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	// Stdlib only offers a few zlib levels, the nearest one is taken
+	encoder := &png.Encoder{CompressionLevel: png.BestCompression}
+	switch {
+	case level <= 3:
+		encoder.CompressionLevel = png.BestSpeed
+	case level <= 6:
+		encoder.CompressionLevel = png.DefaultCompression
+	}
+
+	newBuff := new(bytes.Buffer)
+	if err := encoder.Encode(newBuff, helper.Quantize(nrgba, colors, params.PNGDither)); err != nil {
+		u.Log.Warnf("Failed to encode png : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return newBuff.Bytes(), nil
+}
+
+// Encodes Mat into static gif, colors are quantized into a 256 colors palette with dithering
+func (u *ImageUseCase) encodeGIF(mat gocv.Mat) ([]byte, error) {
+	img, err := mat.ToImage()
+	if err != nil {
+		u.Log.Warnf("Failed to convert Mat to image : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	newBuff := new(bytes.Buffer)
+	if err := gif.Encode(newBuff, img, &gif.Options{NumColors: 256}); err != nil {
+		u.Log.Warnf("Failed to encode gif : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return newBuff.Bytes(), nil
+}
+
+// Maximum number of times the image is downscaled while searching for the target size
+const targetSizeMaxDownscales = 5
+
+// Encodes Mat with the compress options, searching the highest quality fitting the target size when it is given.
+// When even the lowest quality does not fit, the image is downscaled and searched again if it is allowed
+func (u *ImageUseCase) compressToSize(src gocv.Mat, contentType string, params *model.CompressParams) (*encodedImage, error) {
+	if params.TargetSizeInKB > 0 && params.MinSSIM > 0 {
+		u.Log.Warn("Validation error : both target size and minimum SSIM are given")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'target_size_kb' and 'min_ssim' can not be used together")
+	}
+	if params.LumaQuality > 0 && (params.TargetSizeInKB > 0 || params.MinSSIM > 0) {
+		u.Log.Warn("Validation error : luma quality is given along with quality search")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'luma_quality' can not be used with 'target_size_kb' or 'min_ssim'")
+	}
+	if params.MinSSIM > 0 {
+		return u.compressToSSIM(src, contentType, params)
+	}
+	if params.TargetSizeInKB < 1 {
+		newImageBytes, err := u.compressMat(src, contentType, params)
+		if err != nil {
+			return nil, err
+		}
+		return &encodedImage{
+			Bytes:       newImageBytes,
+			ContentType: contentType,
+			Width:       src.Cols(),
+			Height:      src.Rows(),
+			Quality:     compressQuality(contentType, params),
+		}, nil
+	}
+
+	// Validate if target format has encoder quality
+	if !slices.Contains([]string{"image/jpg", "image/jpeg", "image/webp"}, contentType) {
+		u.Log.Warnf("Validation error : target size is not supported for %s", contentType)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'target_size_kb' is only supported for jpeg and webp")
+	}
+
+	targetSize := params.TargetSizeInKB * 1000
+	// Downscaled Mat is owned here, the source is closed by the caller
+	mat, owned := src, false
+	defer func() {
+		if owned {
+			mat.Close()
+		}
+	}()
+	for downscales := 0; ; downscales++ {
+		result, smallestSize, err := u.searchQuality(mat, contentType, targetSize, &params.JPEGParams)
+		if err != nil || result != nil {
+			return result, err
+		}
+		if !params.AllowDownscale || downscales == targetSizeMaxDownscales {
+			break
+		}
+
+		// Encoded size is roughly proportional to the pixel count, so both sides are scaled by square root
+		// of the remaining ratio, with some margin to avoid many small steps
+		scale := math.Sqrt(float64(targetSize)/float64(smallestSize)) * 0.9
+		width, height := scaleOf(float64(mat.Cols()), scale), scaleOf(float64(mat.Rows()), scale)
+		if width == mat.Cols() && height == mat.Rows() {
+			break
+		}
+		downscaled := resizeMatTo(mat, width, height, &model.ResizeParams{Interpolation: "area"})
+		if owned {
+			mat.Close()
+		}
+		mat, owned = downscaled, true
+	}
+
+	u.Log.Warnf("Validation error : image can not be compressed under %d KB", params.TargetSizeInKB)
+	return nil, fiber.NewError(fiber.StatusBadRequest,
+		fmt.Sprintf("image can not be compressed under %d KB", params.TargetSizeInKB))
+}
+
+// Binary searches the lowest quality whose output keeps SSIM against the source at least the given minimum
+func (u *ImageUseCase) compressToSSIM(src gocv.Mat, contentType string, params *model.CompressParams) (*encodedImage, error) {
+	// Validate if target format has encoder quality
+	if !slices.Contains([]string{"image/jpg", "image/jpeg", "image/webp"}, contentType) {
+		u.Log.Warnf("Validation error : minimum SSIM is not supported for %s", contentType)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'min_ssim' is only supported for jpeg and webp")
+	}
+
+	var result *encodedImage
+	for low, high := 1, 99; low <= high; {
+		quality := (low + high) / 2
+		newImageBytes, err := u.encodeMatWithParams(src, contentType, quality, &params.JPEGParams)
+		if err != nil {
+			return nil, err
+		}
+
+		candidate := &encodedImage{
+			Bytes:       newImageBytes,
+			ContentType: contentType,
+			Width:       src.Cols(),
+			Height:      src.Rows(),
+			Quality:     quality,
+		}
+		if err := u.measure(src, candidate); err != nil {
+			return nil, err
+		}
+		if *candidate.SSIM >= params.MinSSIM {
+			result = candidate
+			high = quality - 1
+		} else {
+			low = quality + 1
+		}
+	}
+
+	if result == nil {
+		u.Log.Warnf("Validation error : image can not be compressed with SSIM of at least %v", params.MinSSIM)
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("image can not be compressed with SSIM of at least %v", params.MinSSIM))
+	}
+	return result, nil
+}
+
+// Binary searches the highest quality whose output fits the target size, nil is returned when none fits
+// along with the size of the lowest quality
+func (u *ImageUseCase) searchQuality(mat gocv.Mat, contentType string, targetSize int,
+	jpeg *model.JPEGParams) (*encodedImage, int, error) {
+	var result *encodedImage
+	smallestSize := 0
+	for low, high := 1, 99; low <= high; {
+		quality := (low + high) / 2
+		newImageBytes, err := u.encodeMatWithParams(mat, contentType, quality, jpeg)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(newImageBytes) <= targetSize {
+			result = &encodedImage{
+				Bytes:       newImageBytes,
+				ContentType: contentType,
+				Width:       mat.Cols(),
+				Height:      mat.Rows(),
+				Quality:     quality,
+			}
+			low = quality + 1
+		} else {
+			smallestSize = len(newImageBytes)
+			high = quality - 1
+		}
+	}
+
+	return result, smallestSize, nil
+}
+
+// Uploads original and result image into storage, then records the history of the operation
+func (u *ImageUseCase) save(ctx context.Context, historyType string, original *encodedImage, originalIDPrefix string,
+	result *encodedImage, resultIDPrefix string, parameters any) (*model.ImageResponse, error) {
+	// Creating history
+	newHistory := &entity.History{
+		Timestamp:        time.Now(),
+		Type:             historyType,
+		ExtensionBefore:  original.ContentType,
+		ExtensionAfter:   result.ContentType,
+		SizeBeforeInMB:   helper.ConvertByteToMB(len(original.Bytes)),
+		SizeAfterInMB:    helper.ConvertByteToMB(len(result.Bytes)),
+		HeightBeforeInPx: original.Height,
+		WidthBeforeInPx:  original.Width,
+		HeightAfterInPx:  result.Height,
+		WidthAfterInPx:   result.Width,
+	}
+	if result.Quality > 0 {
+		newHistory.Quality = &result.Quality
+	}
+	newHistory.SSIM = result.SSIM
+	newHistory.PSNR = result.PSNR
+	newHistory.WatermarkPublicIDs = result.WatermarkIDs
+	if parameters != nil {
+		parametersJSON, err := json.Marshal(parameters)
+		if err != nil {
+			u.Log.Warnf("Failed to encode history parameters : %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		newHistory.Parameters = parametersJSON
+	}
+
+	// Upload original image to storage
+	originalID := originalIDPrefix + uuid.New().String()
+	originalURL, err := u.Storage.Put(ctx, originalID, bytes.NewReader(original.Bytes), original.ContentType)
+	if err != nil {
+		u.Log.Warnf("Failed to upload original image : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	newHistory.ImageLinkBefore = originalURL
+	newHistory.ImagePublicIDBefore = originalID
+
+	// Upload result image to storage
+	resultID := resultIDPrefix + uuid.New().String()
+	resultURL, err := u.Storage.Put(ctx, resultID, bytes.NewReader(result.Bytes), result.ContentType)
+	if err != nil {
+		u.Log.Warnf("Failed to upload converted image : %+v", err)
+		u.discard(ctx, originalID)
+		return nil, fiber.ErrInternalServerError
+	}
+	newHistory.ImageLinkAfter = resultURL
+	newHistory.ImagePublicIDAfter = resultID
+
+	// Commit history into DB, the uploaded images are removed when it fails as no history links to them
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.HistoryRepository.Repository.Create(tx, newHistory); err != nil {
+		u.Log.Warnf("Error adding history : %+v", err)
+		u.discard(ctx, originalID, resultID)
+		return nil, fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Error committing history : %+v", err)
+		u.discard(ctx, originalID, resultID)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := &model.ImageResponse{
+		OriginalImageLink: originalURL,
+		ResultImageLink:   resultURL,
+	}
+	if result.Quality > 0 {
+		response.Quality = result.Quality
+		response.SizeInKB = float64(len(result.Bytes)) / 1000
+	}
+	response.SSIM = result.SSIM
+	response.PSNR = result.PSNR
+	return response, nil
+}
+
+// Removes uploaded images which no history links to, failing to remove them is only logged.
+// Removal goes on even when the request is canceled, which is often why saving failed
+func (u *ImageUseCase) discard(ctx context.Context, publicIDs ...string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, publicID := range publicIDs {
+		if err := u.Storage.Delete(ctx, publicID); err != nil {
+			u.Log.Warnf("Failed to delete image %s from storage : %+v", publicID, err)
+		}
+	}
+}
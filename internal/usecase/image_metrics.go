@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"image"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+// PSNR reported for identical images, where it is infinite
+const maxPSNR = 100
+
+// Measures the encoded result against the original Mat, the result is decoded as it would be seen
+func (u *ImageUseCase) measure(original gocv.Mat, result *encodedImage) error {
+	resultMat, err := u.decodeMat(result.Bytes, false)
+	if err != nil {
+		return err
+	}
+	defer resultMat.Close()
+
+	ssim, psnr := measureQuality(original, resultMat)
+	result.SSIM = &ssim
+	result.PSNR = &psnr
+	return nil
+}
+
+// Full reference quality of the result against the original, the result is resized into the original
+// dimension first. Returns mean SSIM (0-1) and PSNR in dB, both averaged over the color channels
+func measureQuality(original gocv.Mat, result gocv.Mat) (float64, float64) {
+	reference := toFloatBGR(original)
+	defer reference.Close()
+
+	resized := gocv.NewMat()
+	defer resized.Close()
+	if result.Cols() != original.Cols() || result.Rows() != original.Rows() {
+		gocv.Resize(result, &resized, image.Point{X: original.Cols(), Y: original.Rows()}, 0, 0, gocv.InterpolationCubic)
+	} else {
+		result.CopyTo(&resized)
+	}
+	distorted := toFloatBGR(resized)
+	defer distorted.Close()
+
+	return roundMetric(ssim(reference, distorted)), roundMetric(psnr(reference, distorted))
+}
+
+// Converts Mat of any channel count into 3 channels float Mat, alpha is dropped
+func toFloatBGR(src gocv.Mat) gocv.Mat {
+	bgr := gocv.NewMat()
+	defer bgr.Close()
+	switch src.Channels() {
+	case 1:
+		gocv.CvtColor(src, &bgr, gocv.ColorGrayToBGR)
+	case 4:
+		gocv.CvtColor(src, &bgr, gocv.ColorBGRAToBGR)
+	default:
+		src.CopyTo(&bgr)
+	}
+
+	dst := gocv.NewMat()
+	bgr.ConvertTo(&dst, gocv.MatTypeCV32FC3)
+	return dst
+}
+
+func psnr(reference gocv.Mat, distorted gocv.Mat) float64 {
+	diff := gocv.NewMat()
+	defer diff.Close()
+	gocv.Subtract(reference, distorted, &diff)
+	gocv.Multiply(diff, diff, &diff)
+
+	mse := meanOfChannels(diff)
+	if mse == 0 {
+		return maxPSNR
+	}
+	return min(10*math.Log10(255*255/mse), maxPSNR)
+}
+
+// Structural similarity with 11x11 gaussian window, as described by Wang et al.
+func ssim(reference gocv.Mat, distorted gocv.Mat) float64 {
+	const c1, c2 = 6.5025, 58.5225 // (0.01 * 255)^2, (0.03 * 255)^2
+
+	blur := func(src gocv.Mat) gocv.Mat {
+		dst := gocv.NewMat()
+		gocv.GaussianBlur(src, &dst, image.Point{X: 11, Y: 11}, 1.5, 1.5, gocv.BorderDefault)
+		return dst
+	}
+	multiply := func(src1, src2 gocv.Mat) gocv.Mat {
+		dst := gocv.NewMat()
+		gocv.Multiply(src1, src2, &dst)
+		return dst
+	}
+
+	mu1, mu2 := blur(reference), blur(distorted)
+	defer mu1.Close()
+	defer mu2.Close()
+	mu1Sq, mu2Sq, mu1Mu2 := multiply(mu1, mu1), multiply(mu2, mu2), multiply(mu1, mu2)
+	defer mu1Sq.Close()
+	defer mu2Sq.Close()
+	defer mu1Mu2.Close()
+
+	// Variances and covariance, E[xy] - E[x]E[y]
+	referenceSq, distortedSq, product := multiply(reference, reference), multiply(distorted, distorted), multiply(reference, distorted)
+	defer referenceSq.Close()
+	defer distortedSq.Close()
+	defer product.Close()
+	sigma1Sq, sigma2Sq, sigma12 := blur(referenceSq), blur(distortedSq), blur(product)
+	defer sigma1Sq.Close()
+	defer sigma2Sq.Close()
+	defer sigma12.Close()
+	gocv.Subtract(sigma1Sq, mu1Sq, &sigma1Sq)
+	gocv.Subtract(sigma2Sq, mu2Sq, &sigma2Sq)
+	gocv.Subtract(sigma12, mu1Mu2, &sigma12)
+
+	// (2 mu1 mu2 + c1)(2 sigma12 + c2) / ((mu1^2 + mu2^2 + c1)(sigma1^2 + sigma2^2 + c2))
+	t1, t2 := gocv.NewMat(), gocv.NewMat()
+	defer t1.Close()
+	defer t2.Close()
+	mu1Mu2.ConvertToWithParams(&t1, gocv.MatTypeCV32FC3, 2, c1)
+	sigma12.ConvertToWithParams(&t2, gocv.MatTypeCV32FC3, 2, c2)
+	numerator := multiply(t1, t2)
+	defer numerator.Close()
+
+	gocv.AddWeighted(mu1Sq, 1, mu2Sq, 1, c1, &t1)
+	gocv.AddWeighted(sigma1Sq, 1, sigma2Sq, 1, c2, &t2)
+	denominator := multiply(t1, t2)
+	defer denominator.Close()
+
+	ssimMap := gocv.NewMat()
+	defer ssimMap.Close()
+	gocv.Divide(numerator, denominator, &ssimMap)
+
+	return meanOfChannels(ssimMap)
+}
+
+// Mean of every pixel averaged over 3 channels
+func meanOfChannels(src gocv.Mat) float64 {
+	mean := src.Mean()
+	return (mean.Val1 + mean.Val2 + mean.Val3) / 3
+}
+
+// Rounds metric to 4 decimals
+func roundMetric(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"image"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+func (u *ImageUseCase) RotateImage(ctx context.Context, request *model.ImageRotateRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.rotateImage(ctx, &request.RotateParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) rotateImage(ctx context.Context, params *model.RotateParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Perform rotation and flipping
+	newMat, err := u.rotateMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	rotated := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
+	}
+	return u.save(ctx, "rotate_image", original, "original_", rotated, "rotated_", params)
+}
+
+func (u *ImageUseCase) rotateStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.RotateParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.rotateMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Rotates the Mat clockwise, then flips it
+func (u *ImageUseCase) rotateMat(src gocv.Mat, params *model.RotateParams) (gocv.Mat, error) {
+	// If 'background_color' is empty, set default as white
+	backgroundColor := params.BackgroundColor
+	if backgroundColor == "" {
+		backgroundColor = "#ffffff"
+	}
+	background, err := helper.ParseHexColor(backgroundColor)
+	if err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'background_color' should be a hex color")
+	}
+
+	// Right angles are rotated by transposing pixels, so there is no interpolation nor background
+	rotated := gocv.NewMat()
+	angle := math.Mod(params.AngleInDegrees, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	switch angle {
+	case 0:
+		src.CopyTo(&rotated)
+	case 90:
+		gocv.Rotate(src, &rotated, gocv.Rotate90Clockwise)
+	case 180:
+		gocv.Rotate(src, &rotated, gocv.Rotate180Clockwise)
+	case 270:
+		gocv.Rotate(src, &rotated, gocv.Rotate90CounterClockwise)
+	default:
+		// Enlarge the canvas to fit the rotated image, OpenCV angle is counter clockwise
+		radians := angle * math.Pi / 180
+		cos, sin := math.Abs(math.Cos(radians)), math.Abs(math.Sin(radians))
+		width := int(math.Ceil(float64(src.Cols())*cos + float64(src.Rows())*sin))
+		height := int(math.Ceil(float64(src.Cols())*sin + float64(src.Rows())*cos))
+		center := image.Point{X: src.Cols() / 2, Y: src.Rows() / 2}
+
+		matrix := gocv.GetRotationMatrix2D(center, -angle, 1)
+		defer matrix.Close()
+		matrix.SetDoubleAt(0, 2, matrix.GetDoubleAt(0, 2)+float64(width/2-center.X))
+		matrix.SetDoubleAt(1, 2, matrix.GetDoubleAt(1, 2)+float64(height/2-center.Y))
+
+		gocv.WarpAffineWithParams(src, &rotated, matrix, image.Point{X: width, Y: height},
+			gocv.InterpolationCubic, gocv.BorderConstant, background)
+	}
+
+	if params.Flip == "" {
+		return rotated, nil
+	}
+
+	// Flip code follows OpenCV, 1 is around the vertical axis, 0 around the horizontal axis, -1 both
+	flipCode := map[string]int{"horizontal": 1, "vertical": 0, "both": -1}[params.Flip]
+	flipped := gocv.NewMat()
+	gocv.Flip(rotated, &flipped, flipCode)
+	rotated.Close()
+
+	return flipped, nil
+}
+
+// Applies EXIF orientation (1-8) in place, so the Mat is upright as the image was displayed
+func orientMat(mat *gocv.Mat, orientation int) {
+	oriented := gocv.NewMat()
+	switch orientation {
+	case 2:
+		gocv.Flip(*mat, &oriented, 1)
+	case 3:
+		gocv.Rotate(*mat, &oriented, gocv.Rotate180Clockwise)
+	case 4:
+		gocv.Flip(*mat, &oriented, 0)
+	case 5:
+		gocv.Transpose(*mat, &oriented)
+	case 6:
+		gocv.Rotate(*mat, &oriented, gocv.Rotate90Clockwise)
+	case 7:
+		// Transverse, transposed along the anti-diagonal
+		transposed := gocv.NewMat()
+		defer transposed.Close()
+		gocv.Transpose(*mat, &transposed)
+		gocv.Rotate(transposed, &oriented, gocv.Rotate180Clockwise)
+	case 8:
+		gocv.Rotate(*mat, &oriented, gocv.Rotate90CounterClockwise)
+	default:
+		oriented.Close()
+		return
+	}
+
+	mat.Close()
+	*mat = oriented
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"go-image-api/internal/model"
+	"image"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+// Longest side of the downscaled copy analysed by smart gravity
+const smartMaxSide = 640
+
+// Returns the top left point to place a box of the given size inside the Mat, by gravity.
+// Smart gravity is resolved from the content of the Mat, others as gravityOrigin
+func (u *ImageUseCase) placeBox(src gocv.Mat, gravity string, boxWidth, boxHeight int) image.Point {
+	if gravity == "smart" {
+		return u.smartOrigin(src, boxWidth, boxHeight)
+	}
+
+	return gravityOrigin(gravity, src.Cols(), src.Rows(), boxWidth, boxHeight)
+}
+
+// Places the box centered on the detected faces, or on the most detailed area when no face is found
+func (u *ImageUseCase) smartOrigin(src gocv.Mat, boxWidth, boxHeight int) image.Point {
+	width, height := src.Cols(), src.Rows()
+	// Nothing to choose when the box covers the whole image, or does not fit in it
+	if boxWidth >= width && boxHeight >= height || boxWidth > width || boxHeight > height {
+		return gravityOrigin("center", width, height, boxWidth, boxHeight)
+	}
+
+	// Analyse a downscaled grayscale copy, it is enough to locate the subject and much faster
+	gray := gocv.NewMat()
+	defer gray.Close()
+	switch src.Channels() {
+	case 1:
+		src.CopyTo(&gray)
+	case 4:
+		gocv.CvtColor(src, &gray, gocv.ColorBGRAToGray)
+	default:
+		gocv.CvtColor(src, &gray, gocv.ColorBGRToGray)
+	}
+	scale := min(float64(smartMaxSide)/float64(max(width, height)), 1)
+	small := resizeMatTo(gray, scaleOf(float64(width), scale), scaleOf(float64(height), scale),
+		&model.ResizeParams{Interpolation: "area"})
+	defer small.Close()
+
+	if faces := u.detectFaces(small); len(faces) > 0 {
+		focus := faces[0]
+		for _, face := range faces[1:] {
+			focus = focus.Union(face)
+		}
+		// Detected faces leave out hair and chin, so the focus is widened to keep the whole head
+		focus = focus.Inset(-focus.Dy() / 4)
+
+		centerX := int(math.Round(float64(focus.Min.X+focus.Dx()/2) / scale))
+		centerY := int(math.Round(float64(focus.Min.Y+focus.Dy()/2) / scale))
+		return image.Point{
+			X: min(max(centerX-boxWidth/2, 0), width-boxWidth),
+			Y: min(max(centerY-boxHeight/2, 0), height-boxHeight),
+		}
+	}
+
+	origin := detailOrigin(small, scaleOf(float64(boxWidth), scale), scaleOf(float64(boxHeight), scale))
+	return image.Point{
+		X: min(int(math.Round(float64(origin.X)/scale)), width-boxWidth),
+		Y: min(int(math.Round(float64(origin.Y)/scale)), height-boxHeight),
+	}
+}
+
+// Detects frontal faces in the grayscale Mat, nothing is detected when the cascade can not be loaded
+func (u *ImageUseCase) detectFaces(gray gocv.Mat) []image.Rectangle {
+	u.faceCascadeOnce.Do(func() {
+		// If cascade path is not configured, set defaults to the cascade shipped in assets
+		path := u.ViperConfig.GetString("FACE_CASCADE_PATH")
+		if path == "" {
+			path = "assets/haarcascade_frontalface_default.xml"
+		}
+
+		classifier := gocv.NewCascadeClassifier()
+		if !classifier.Load(path) {
+			u.Log.Warnf("Failed to load face cascade %s, smart gravity only uses image detail", path)
+			classifier.Close()
+			return
+		}
+		u.faceCascade = &classifier
+	})
+	if u.faceCascade == nil {
+		return nil
+	}
+
+	equalized := gocv.NewMat()
+	defer equalized.Close()
+	gocv.EqualizeHist(gray, &equalized)
+
+	// Classifier is not safe for concurrent use, batch images are detected one at a time
+	u.faceCascadeLock.Lock()
+	defer u.faceCascadeLock.Unlock()
+	minSide := max(min(gray.Cols(), gray.Rows())/20, 20)
+	return u.faceCascade.DetectMultiScaleWithParams(equalized, 1.1, 5, 0,
+		image.Point{X: minSide, Y: minSide}, image.Point{})
+}
+
+// Finds the box of the given size covering the most detail of the grayscale Mat, measured as absolute
+// laplacian. Ties are resolved towards the center
+func detailOrigin(gray gocv.Mat, boxWidth, boxHeight int) image.Point {
+	laplacian := gocv.NewMat()
+	defer laplacian.Close()
+	gocv.Laplacian(gray, &laplacian, gocv.MatTypeCV16S, 3, 1, 0, gocv.BorderDefault)
+	detail := gocv.NewMat()
+	defer detail.Close()
+	gocv.ConvertScaleAbs(laplacian, &detail, 1, 0)
+	// Smoothed, so a single sharp edge does not outweigh a textured area
+	gocv.GaussianBlur(detail, &detail, image.Point{}, 2, 2, gocv.BorderDefault)
+
+	// Summed area table with a leading row and column of zeros, so any box sum takes 4 lookups
+	cols, rows := detail.Cols(), detail.Rows()
+	pixels := detail.ToBytes()
+	sums := make([]int, (cols+1)*(rows+1))
+	for y := 0; y < rows; y++ {
+		rowSum := 0
+		for x := 0; x < cols; x++ {
+			rowSum += int(pixels[y*cols+x])
+			sums[(y+1)*(cols+1)+x+1] = sums[y*(cols+1)+x+1] + rowSum
+		}
+	}
+
+	boxWidth, boxHeight = min(max(boxWidth, 1), cols), min(max(boxHeight, 1), rows)
+	center := image.Point{X: (cols - boxWidth) / 2, Y: (rows - boxHeight) / 2}
+	distance := func(p image.Point) int {
+		d := p.Sub(center)
+		return d.X*d.X + d.Y*d.Y
+	}
+	best, bestSum := center, -1
+	for y := 0; y <= rows-boxHeight; y++ {
+		for x := 0; x <= cols-boxWidth; x++ {
+			sum := sums[(y+boxHeight)*(cols+1)+x+boxWidth] - sums[y*(cols+1)+x+boxWidth] -
+				sums[(y+boxHeight)*(cols+1)+x] + sums[y*(cols+1)+x]
+			point := image.Point{X: x, Y: y}
+			if sum > bestSum || sum == bestSum && distance(point) < distance(best) {
+				best, bestSum = point, sum
+			}
+		}
+	}
+
+	return best
+}
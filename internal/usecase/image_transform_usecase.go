@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-image-api/internal/model"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"gocv.io/x/gocv"
+)
+
+// Image being transformed along with how it will be encoded at the end of the pipeline
+type transformState struct {
+	Mat         gocv.Mat
+	ContentType string
+	Compress    *model.CompressParams // Compress options of the last compress operation, if any
+	JPEG        *model.JPEGParams     // JPEG options of the last compress or convert operation, if any
+}
+
+// Replaces the Mat of the state, closing the previous one
+func (s *transformState) replace(mat gocv.Mat) {
+	s.Mat.Close()
+	s.Mat = mat
+}
+
+// Decodes and validates the parameters of an operation, then applies it into the state
+type transformStep func(state *transformState, params json.RawMessage) error
+
+// Registered pipeline operations, new operation only needs to be added here
+func (u *ImageUseCase) transformSteps() map[string]transformStep {
+	return map[string]transformStep{
+		"resize":    u.resizeStep,
+		"compress":  u.compressStep,
+		"convert":   u.convertStep,
+		"crop":      u.cropStep,
+		"rotate":    u.rotateStep,
+		"watermark": u.watermarkStep,
+		"filter":    u.filterStep,
+		"enhance":   u.enhanceStep,
+		"denoise":   u.denoiseStep,
+	}
+}
+
+func (u *ImageUseCase) TransformImage(ctx context.Context, request *model.ImageTransformRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.transformImage(ctx, &request.TransformParams, originalImageBytes)
+}
+
+func (u *ImageUseCase) transformImage(ctx context.Context, params *model.TransformParams,
+	originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate every operation is known before doing any work
+	steps := u.transformSteps()
+	for i, operation := range params.Operations {
+		if _, ok := steps[operation.Type]; !ok {
+			u.Log.Warnf("Validation error : unknown operation '%s'", operation.Type)
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("operations[%d] has unknown type '%s'", i, operation.Type))
+		}
+	}
+
+	// Validate if file is in png, jpeg, webp, bmp, tiff, or gif
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+		"image/webp",
+		"image/bmp",
+		"image/tiff",
+		"image/gif",
+	}
+	contentType := detectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpeg, webp, bmp, tiff, or gif")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpeg, webp, bmp, tiff, or gif")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Apply every operation in order on a single Mat
+	state := &transformState{
+		Mat:         originalMat.Clone(),
+		ContentType: contentType,
+	}
+	defer func() { state.Mat.Close() }()
+	for _, operation := range params.Operations {
+		if err := steps[operation.Type](state, operation.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert Mat into bytes, only the final result is encoded
+	transformed := &encodedImage{
+		ContentType: state.ContentType,
+		Width:       state.Mat.Cols(),
+		Height:      state.Mat.Rows(),
+	}
+	if state.Compress != nil {
+		compress := *state.Compress
+		compress.JPEGParams = *state.JPEG
+		transformed, err = u.compressToSize(state.Mat, state.ContentType, &compress)
+	} else {
+		transformed.Bytes, err = u.encodeMatWithParams(state.Mat, state.ContentType, 0, state.JPEG)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the watermark logos, so the history links to them instead of embedding them
+	recorded := *params
+	recorded.Operations = slices.Clone(params.Operations)
+	logoIDs, err := u.storeOperationLogos(ctx, recorded.Operations)
+	if err != nil {
+		return nil, err
+	}
+	transformed.WatermarkIDs = logoIDs
+
+	// Upload both images and create history with the whole pipeline
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	response, err := u.save(ctx, "transform_image", original, "original_", transformed, "transformed_", &recorded)
+	if err != nil {
+		// No history links to the logos, so they are removed right away
+		u.discard(ctx, logoIDs...)
+	}
+	return response, err
+}
+
+// Decodes operation parameters into the given struct and validates it
+func (u *ImageUseCase) decodeParams(raw json.RawMessage, params any) error {
+	if err := json.Unmarshal(raw, params); err != nil {
+		u.Log.Warnf("Failed to decode operation parameters : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "operation parameters are invalid")
+	}
+	if err := u.Validate.Struct(params); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (u *ImageUseCase) resizeStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.ResizeParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.resizeMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+func (u *ImageUseCase) compressStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.CompressParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	state.Compress = params
+	state.JPEG = &params.JPEGParams
+	return nil
+}
+
+func (u *ImageUseCase) convertStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.ConvertParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.convertMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	state.ContentType = "image/" + params.TargetFormat
+	state.JPEG = &params.JPEGParams
+	return nil
+}
@@ -1,24 +1,19 @@
 package usecase
 
 import (
-	"bytes"
 	"context"
-	"go-image-api/internal/entity"
 	"go-image-api/internal/helper"
 	"go-image-api/internal/model"
 	"go-image-api/internal/repository"
+	"go-image-api/internal/storage"
 	"image"
-	"image/jpeg"
-	"image/png"
+	"math"
 	"net/http"
 	"slices"
-	"time"
+	"sync"
 
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gocv.io/x/gocv"
@@ -30,134 +25,27 @@ type ImageUseCase struct {
 	DB                *gorm.DB
 	Validate          *validator.Validate
 	Log               *logrus.Logger
-	Cloudinary        *cloudinary.Cloudinary
+	Storage           storage.Storage
 	HistoryRepository *repository.HistoryRepository
+
+	// Face detector of smart gravity, loaded on first use
+	faceCascadeOnce sync.Once
+	faceCascadeLock sync.Mutex
+	faceCascade     *gocv.CascadeClassifier
 }
 
 func NewImageUseCase(viperConfig *viper.Viper, db *gorm.DB, validate *validator.Validate, log *logrus.Logger,
-	cld *cloudinary.Cloudinary, historyRepository *repository.HistoryRepository) *ImageUseCase {
+	store storage.Storage, historyRepository *repository.HistoryRepository) *ImageUseCase {
 	return &ImageUseCase{
 		ViperConfig:       viperConfig,
 		DB:                db,
 		Validate:          validate,
 		Log:               log,
-		Cloudinary:        cld,
+		Storage:           store,
 		HistoryRepository: historyRepository,
 	}
 }
 
-func (u *ImageUseCase) ConvertPNGToJPEG(ctx context.Context, request *model.ImageRequest) (*model.ImageResponse, error) {
-	// Validate request
-	if err := u.Validate.Struct(request); err != nil {
-		u.Log.Warnf("Validation error : %+v", err)
-		return nil, err
-	}
-
-	// Open file header
-	imageFile, err := request.ImageFileHeader.Open()
-	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	defer imageFile.Close()
-
-	// Convert file to buffer
-	fileBuff, err := helper.FormFileToBuffer(u.Log, imageFile)
-	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	defer fileBuff.Reset()
-
-	// Validate if file is in png
-	originalImageBytes := fileBuff.Bytes()
-	if http.DetectContentType(originalImageBytes) != "image/png" {
-		u.Log.Warn("Validation error : file is not in png")
-		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png")
-	}
-
-	// Decode image in png
-	imagePng, err := png.Decode(bytes.NewReader(originalImageBytes))
-	if err != nil {
-		u.Log.Warnf("Failed to decode png : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-
-	// Create new buffer in jpeg
-	newBuff := new(bytes.Buffer)
-	if err := jpeg.Encode(newBuff, imagePng, nil); err != nil {
-		u.Log.Warnf("Failed to convert image into jpeg : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	defer newBuff.Reset()
-
-	// Creating history
-	ogBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(fileBuff.Bytes()))
-	if err != nil {
-		u.Log.Warnf("Failed to decode original image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(newBuff.Bytes()))
-	if err != nil {
-		u.Log.Warnf("Failed to decode converted image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory := &entity.History{
-		Timestamp:        time.Now(),
-		Type:             "convert_png_jpeg",
-		ExtensionBefore:  "image/png",
-		ExtensionAfter:   "image/jpg",
-		SizeBeforeInMB:   helper.ConvertByteToMB(len(originalImageBytes)),
-		SizeAfterInMB:    helper.ConvertByteToMB(len(newBuff.Bytes())),
-		HeightBeforeInPx: ogBuffImage.Height,
-		WidthBeforeInPx:  ogBuffImage.Width,
-		HeightAfterInPx:  newBuffImage.Height,
-		WidthAfterInPx:   newBuffImage.Width,
-	}
-
-	// Upload original image to cloudinary
-	uuidOriginal := uuid.New()
-	originalID := "png_" + uuidOriginal.String()
-	originalCldResponse, err := u.Cloudinary.Upload.Upload(ctx, fileBuff, uploader.UploadParams{
-		PublicID: originalID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload original image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkBefore = originalCldResponse.SecureURL
-
-	// Upload converted image to cloudinary
-	uuidConverted := uuid.New()
-	convertedID := "jpeg_" + uuidConverted.String()
-	convertedCldResponse, err := u.Cloudinary.Upload.Upload(ctx, newBuff, uploader.UploadParams{
-		PublicID: convertedID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload converted image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkAfter = convertedCldResponse.SecureURL
-
-	// Commit history into DB
-	tx := u.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
-	if err := u.HistoryRepository.Repository.Create(tx, newHistory); err != nil {
-		u.Log.Warnf("Error adding history : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	if err := tx.Commit().Error; err != nil {
-		u.Log.Warnf("Error committing history : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-
-	response := &model.ImageResponse{
-		OriginalImageLink: originalCldResponse.SecureURL,
-		ResultImageLink:   convertedCldResponse.SecureURL,
-	}
-	return response, nil
-}
-
 func (u *ImageUseCase) ResizeImage(ctx context.Context, request *model.ImageResizeRequest) (*model.ImageResponse, error) {
 	// Validate request
 	if err := u.Validate.Struct(request); err != nil {
@@ -165,29 +53,22 @@ func (u *ImageUseCase) ResizeImage(ctx context.Context, request *model.ImageResi
 		return nil, err
 	}
 
-	// Open file header
-	imageFile, err := request.ImageFileHeader.Open()
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
 	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
-	defer imageFile.Close()
 
-	// Convert file to buffer
-	fileBuff, err := helper.FormFileToBuffer(u.Log, imageFile)
-	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	defer fileBuff.Reset()
+	return u.resizeImage(ctx, &request.ResizeParams, originalImageBytes)
+}
 
+func (u *ImageUseCase) resizeImage(ctx context.Context, params *model.ResizeParams, originalImageBytes []byte) (*model.ImageResponse, error) {
 	// Validate if file is in png, jpg, or jpeg
 	extConstraint := []string{
 		"image/png",
 		"image/jpg",
 		"image/jpeg",
 	}
-	originalImageBytes := fileBuff.Bytes()
 	contentType := http.DetectContentType(originalImageBytes)
 	if !slices.Contains(extConstraint, contentType) {
 		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
@@ -195,101 +76,44 @@ func (u *ImageUseCase) ResizeImage(ctx context.Context, request *model.ImageResi
 	}
 
 	// Convert image bytes to Mat
-	originalMat, err := gocv.IMDecode(originalImageBytes, gocv.IMReadAnyColor)
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
 	if err != nil {
-		u.Log.Warnf("Failed to convert image to Mat : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
+	defer originalMat.Close()
 
 	// Perform resizing
-	newMat := gocv.NewMat()
-	gocv.Resize(originalMat, &newMat, image.Point{
-		X: request.WidthInPixels, Y: request.HeightInPixels}, 0, 0, gocv.InterpolationLinear)
-
-	// Convert Mat into native buffer
-	var newBuffNative *gocv.NativeByteBuffer
-	switch contentType {
-	case "image/png":
-		newBuffNative, err = gocv.IMEncode(".png", newMat)
-	case "image/jpg":
-		newBuffNative, err = gocv.IMEncode(".jpg", newMat)
-	case "image/jpeg":
-		newBuffNative, err = gocv.IMEncode(".jpeg", newMat)
-	}
+	newMat, err := u.resizeMat(originalMat, params)
 	if err != nil {
-		u.Log.Warnf("Failed to convert Mat to buffer : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
+	defer newMat.Close()
 
-	// Convert gocv native buffer to buffer
-	newBuff := bytes.NewBuffer(newBuffNative.GetBytes())
-	defer newBuff.Reset()
-
-	// Creating history
-	ogBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(fileBuff.Bytes()))
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
 	if err != nil {
-		u.Log.Warnf("Failed to decode original image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(newBuff.Bytes()))
-	if err != nil {
-		u.Log.Warnf("Failed to decode converted image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory := &entity.History{
-		Timestamp:        time.Now(),
-		Type:             "resize_image",
-		ExtensionBefore:  contentType,
-		ExtensionAfter:   contentType,
-		SizeBeforeInMB:   helper.ConvertByteToMB(len(originalImageBytes)),
-		SizeAfterInMB:    helper.ConvertByteToMB(len(newBuff.Bytes())),
-		HeightBeforeInPx: ogBuffImage.Height,
-		WidthBeforeInPx:  ogBuffImage.Width,
-		HeightAfterInPx:  newBuffImage.Height,
-		WidthAfterInPx:   newBuffImage.Width,
+		return nil, err
 	}
 
-	// Upload original image to cloudinary
-	uuidOriginal := uuid.New()
-	originalID := "original_" + uuidOriginal.String()
-	originalCldResponse, err := u.Cloudinary.Upload.Upload(ctx, fileBuff, uploader.UploadParams{
-		PublicID: originalID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload original image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkBefore = originalCldResponse.SecureURL
-
-	// Upload converted image to cloudinary
-	uuidConverted := uuid.New()
-	convertedID := "resized_" + uuidConverted.String()
-	convertedCldResponse, err := u.Cloudinary.Upload.Upload(ctx, newBuff, uploader.UploadParams{
-		PublicID: convertedID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload converted image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkAfter = convertedCldResponse.SecureURL
-
-	// Commit history into DB
-	tx := u.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
-	if err := u.HistoryRepository.Repository.Create(tx, newHistory); err != nil {
-		u.Log.Warnf("Error adding history : %+v", err)
-		return nil, fiber.ErrInternalServerError
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
 	}
-	if err := tx.Commit().Error; err != nil {
-		u.Log.Warnf("Error committing history : %+v", err)
-		return nil, fiber.ErrInternalServerError
+	resized := &encodedImage{
+		Bytes:       newImageBytes,
+		ContentType: contentType,
+		Width:       newMat.Cols(),
+		Height:      newMat.Rows(),
 	}
 
-	response := &model.ImageResponse{
-		OriginalImageLink: originalCldResponse.SecureURL,
-		ResultImageLink:   convertedCldResponse.SecureURL,
+	// Measure quality against the original, the result is scaled back into the original dimension
+	if err := u.measure(originalMat, resized); err != nil {
+		return nil, err
 	}
-	return response, nil
+	return u.save(ctx, "resize_image", original, "original_", resized, "resized_", params)
 }
 
 func (u *ImageUseCase) CompressImage(ctx context.Context, request *model.ImageCompressRequest) (*model.ImageResponse, error) {
@@ -299,127 +123,180 @@ func (u *ImageUseCase) CompressImage(ctx context.Context, request *model.ImageCo
 		return nil, err
 	}
 
-	// Open file header
-	imageFile, err := request.ImageFileHeader.Open()
+	// Read uploaded image
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
 	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
-	defer imageFile.Close()
 
-	// Convert file to buffer
-	fileBuff, err := helper.FormFileToBuffer(u.Log, imageFile)
-	if err != nil {
-		u.Log.Warnf("Failed to open file content : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	defer fileBuff.Reset()
+	return u.compressImage(ctx, &request.CompressParams, originalImageBytes)
+}
 
-	// Validate if file is in png, jpg, or jpeg
+func (u *ImageUseCase) compressImage(ctx context.Context, params *model.CompressParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, jpeg, or webp
 	extConstraint := []string{
 		"image/png",
 		"image/jpg",
 		"image/jpeg",
+		"image/webp",
 	}
-	originalImageBytes := fileBuff.Bytes()
 	contentType := http.DetectContentType(originalImageBytes)
 	if !slices.Contains(extConstraint, contentType) {
-		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
-		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+		u.Log.Warn("Validation error : file is not in png, jpg, jpeg, or webp")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, jpeg, or webp")
 	}
 
 	// Convert image bytes to Mat
-	originalMat, err := gocv.IMDecode(originalImageBytes, gocv.IMReadAnyColor)
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
 	if err != nil {
-		u.Log.Warnf("Failed to convert image to Mat : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
+	defer originalMat.Close()
 
-	// Petform compression
-	var newBuffNative *gocv.NativeByteBuffer
-	switch contentType {
-	case "image/png":
-		newBuffNative, err = gocv.IMEncodeWithParams(".png", originalMat, []int{
-			gocv.IMWriteJpegQuality, request.CompressQuality})
-	case "image/jpg":
-		newBuffNative, err = gocv.IMEncodeWithParams(".jpg", originalMat, []int{
-			gocv.IMWriteJpegQuality, request.CompressQuality})
-	case "image/jpeg":
-		newBuffNative, err = gocv.IMEncodeWithParams(".jpeg", originalMat, []int{
-			gocv.IMWriteJpegQuality, request.CompressQuality})
-	}
+	// Petform compression, searching the quality when target size is given
+	compressed, err := u.compressToSize(originalMat, contentType, params)
 	if err != nil {
-		u.Log.Warnf("Failed to convert Mat to buffer : %+v", err)
-		return nil, fiber.ErrInternalServerError
+		return nil, err
 	}
 
-	// Convert gocv native buffer to buffer
-	newBuff := bytes.NewBuffer(newBuffNative.GetBytes())
-	defer newBuff.Reset()
-
-	// Creating history
-	ogBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(fileBuff.Bytes()))
-	if err != nil {
-		u.Log.Warnf("Failed to decode original image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newBuffImage, _, err := image.DecodeConfig(bytes.NewBuffer(newBuff.Bytes()))
-	if err != nil {
-		u.Log.Warnf("Failed to decode converted image buffer to image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory := &entity.History{
-		Timestamp:        time.Now(),
-		Type:             "compress_image",
-		ExtensionBefore:  contentType,
-		ExtensionAfter:   contentType,
-		SizeBeforeInMB:   helper.ConvertByteToMB(len(originalImageBytes)),
-		SizeAfterInMB:    helper.ConvertByteToMB(len(newBuff.Bytes())),
-		HeightBeforeInPx: ogBuffImage.Height,
-		WidthBeforeInPx:  ogBuffImage.Width,
-		HeightAfterInPx:  newBuffImage.Height,
-		WidthAfterInPx:   newBuffImage.Width,
+	// Lossless png may be re-encoded larger than uploaded, keep the upload without its metadata then.
+	// Unless it has EXIF orientation, which would be lost along with the metadata
+	if contentType == "image/png" && pngColors(params) == 0 && helper.ExifOrientation(originalImageBytes) == 1 {
+		if stripped := helper.StripPNGMetadata(originalImageBytes); len(stripped) < len(compressed.Bytes) {
+			compressed.Bytes = stripped
+			compressed.SSIM, compressed.PSNR = nil, nil
+		}
 	}
 
-	// Upload original image to cloudinary
-	uuidOriginal := uuid.New()
-	originalID := "original_" + uuidOriginal.String()
-	originalCldResponse, err := u.Cloudinary.Upload.Upload(ctx, fileBuff, uploader.UploadParams{
-		PublicID: originalID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload original image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkBefore = originalCldResponse.SecureURL
-
-	// Upload converted image to cloudinary
-	uuidConverted := uuid.New()
-	convertedID := "compressed_" + uuidConverted.String()
-	convertedCldResponse, err := u.Cloudinary.Upload.Upload(ctx, newBuff, uploader.UploadParams{
-		PublicID: convertedID,
-	})
-	if err != nil {
-		u.Log.Warnf("Failed to upload converted image : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-	newHistory.ImageLinkAfter = convertedCldResponse.SecureURL
-
-	// Commit history into DB
-	tx := u.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
-	if err := u.HistoryRepository.Repository.Create(tx, newHistory); err != nil {
-		u.Log.Warnf("Error adding history : %+v", err)
-		return nil, fiber.ErrInternalServerError
+	// Measure quality against the original, unless it is already measured by the SSIM search
+	if compressed.SSIM == nil {
+		if err := u.measure(originalMat, compressed); err != nil {
+			return nil, err
+		}
 	}
-	if err := tx.Commit().Error; err != nil {
-		u.Log.Warnf("Error committing history : %+v", err)
-		return nil, fiber.ErrInternalServerError
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
 	}
+	return u.save(ctx, "compress_image", original, "original_", compressed, "compressed_", params)
+}
+
+// Performs resizing by mode, fill stretches into the exact box, contain fits within it, cover fills it
+// and crops the overflow, pad fits within it and fills the rest with background color
+func (u *ImageUseCase) resizeMat(src gocv.Mat, params *model.ResizeParams) (gocv.Mat, error) {
+	srcWidth, srcHeight := float64(src.Cols()), float64(src.Rows())
+	width, height := params.WidthInPixels, params.HeightInPixels
+	if width < 1 && height < 1 {
+		u.Log.Warn("Validation error : resize width and height are empty")
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'width_in_pixels' or 'height_in_pixels' is required")
+	}
+
+	// If 'mode' is empty, set default as fill
+	mode := params.Mode
+	if mode == "" {
+		mode = "fill"
+	}
+
+	// Smart gravity picks which part of the image to keep, so only cover which crops the image can use it
+	if params.Gravity == "smart" && mode != "cover" {
+		u.Log.Warnf("Validation error : smart gravity is given with %s mode", mode)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'gravity' smart is only supported with 'mode' cover")
+	}
+
+	// Only one dimension is given, derive the other one preserving ratio
+	if width < 1 || height < 1 {
+		scale := float64(width) / srcWidth
+		if width < 1 {
+			scale = float64(height) / srcHeight
+		}
+		if params.NoUpscale {
+			scale = min(scale, 1)
+		}
+		return resizeMatTo(src, scaleOf(srcWidth, scale), scaleOf(srcHeight, scale), params), nil
+	}
+
+	switch mode {
+	case "contain", "pad":
+		scale := min(float64(width)/srcWidth, float64(height)/srcHeight)
+		if params.NoUpscale {
+			scale = min(scale, 1)
+		}
+		resized := resizeMatTo(src, scaleOf(srcWidth, scale), scaleOf(srcHeight, scale), params)
+		if mode == "contain" {
+			return resized, nil
+		}
+		defer resized.Close()
+
+		// If 'background_color' is empty, set default as white
+		backgroundColor := params.BackgroundColor
+		if backgroundColor == "" {
+			backgroundColor = "#ffffff"
+		}
+		background, err := helper.ParseHexColor(backgroundColor)
+		if err != nil {
+			u.Log.Warnf("Validation error : %+v", err)
+			return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'background_color' should be a hex color")
+		}
+
+		// Place the resized image inside the box by gravity
+		origin := gravityOrigin(params.Gravity, width, height, resized.Cols(), resized.Rows())
+		padded := gocv.NewMat()
+		gocv.CopyMakeBorder(resized, &padded, origin.Y, height-resized.Rows()-origin.Y,
+			origin.X, width-resized.Cols()-origin.X, gocv.BorderConstant, background)
+		return padded, nil
+	case "cover":
+		scale := max(float64(width)/srcWidth, float64(height)/srcHeight)
+		if params.NoUpscale {
+			scale = min(scale, 1)
+		}
+		resized := resizeMatTo(src, scaleOf(srcWidth, scale), scaleOf(srcHeight, scale), params)
+		defer resized.Close()
+
+		// Crop the overflow by gravity, the box is smaller when upscaling is not allowed
+		cropWidth, cropHeight := min(width, resized.Cols()), min(height, resized.Rows())
+		origin := u.placeBox(resized, params.Gravity, cropWidth, cropHeight)
+		region := resized.Region(image.Rect(origin.X, origin.Y, origin.X+cropWidth, origin.Y+cropHeight))
+		defer region.Close()
+		return region.Clone(), nil
+	}
+
+	// Fill, every dimension is kept within the original when upscaling is not allowed
+	if params.NoUpscale {
+		width, height = min(width, src.Cols()), min(height, src.Rows())
+	}
+	return resizeMatTo(src, width, height, params), nil
+}
+
+// Interpolation algorithms accepted by resizing
+var interpolationFlags = map[string]gocv.InterpolationFlags{
+	"nearest":  gocv.InterpolationNearestNeighbor,
+	"linear":   gocv.InterpolationLinear,
+	"cubic":    gocv.InterpolationCubic,
+	"area":     gocv.InterpolationArea,
+	"lanczos4": gocv.InterpolationLanczos4,
+}
 
-	response := &model.ImageResponse{
-		OriginalImageLink: originalCldResponse.SecureURL,
-		ResultImageLink:   convertedCldResponse.SecureURL,
+// Performs resizing into the exact dimension. If interpolation is empty, it is set to area when
+// downscaling and cubic when upscaling, so the params record the algorithm actually used
+func resizeMatTo(src gocv.Mat, width, height int, params *model.ResizeParams) gocv.Mat {
+	if params.Interpolation == "" {
+		params.Interpolation = "cubic"
+		if width*height < src.Cols()*src.Rows() {
+			params.Interpolation = "area"
+		}
 	}
-	return response, nil
+
+	dst := gocv.NewMat()
+	gocv.Resize(src, &dst, image.Point{X: width, Y: height}, 0, 0, interpolationFlags[params.Interpolation])
+
+	return dst
+}
+
+// Returns the scaled length, rounded to the nearest pixel and at least a pixel
+func scaleOf(length, scale float64) int {
+	return max(int(math.Round(length*scale)), 1)
 }
@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"image"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gocv.io/x/gocv"
+)
+
+// Tiled watermark limits, a tile is at least this wide and an image holds at most this many tiles
+const (
+	watermarkMinTileWidth = 32
+	watermarkMaxTiles     = 2500
+)
+
+func (u *ImageUseCase) WatermarkImage(ctx context.Context, request *model.ImageWatermarkRequest) (*model.ImageResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	// Read uploaded image and logo
+	originalImageBytes, err := u.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.ReadWatermark(request); err != nil {
+		return nil, err
+	}
+
+	return u.watermarkImage(ctx, &request.WatermarkParams, originalImageBytes)
+}
+
+// Reads the uploaded logo into the watermark params, so they can be queued as a job along with it
+func (u *ImageUseCase) ReadWatermark(request *model.ImageWatermarkRequest) error {
+	if request.WatermarkFileHeader == nil {
+		return nil
+	}
+
+	logoBytes, err := u.readImage(request.WatermarkFileHeader)
+	if err != nil {
+		return err
+	}
+	request.Image = logoBytes
+	return nil
+}
+
+func (u *ImageUseCase) watermarkImage(ctx context.Context, params *model.WatermarkParams, originalImageBytes []byte) (*model.ImageResponse, error) {
+	// Validate if file is in png, jpg, or jpeg
+	extConstraint := []string{
+		"image/png",
+		"image/jpg",
+		"image/jpeg",
+	}
+	contentType := http.DetectContentType(originalImageBytes)
+	if !slices.Contains(extConstraint, contentType) {
+		u.Log.Warn("Validation error : file is not in png, jpg, or jpeg")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file should be in png, jpg, or jpeg")
+	}
+
+	// Convert image bytes to Mat
+	originalMat, err := u.decodeMat(originalImageBytes, params.AutoOrient)
+	if err != nil {
+		return nil, err
+	}
+	defer originalMat.Close()
+
+	// Perform watermarking
+	newMat, err := u.watermarkMat(originalMat, params)
+	if err != nil {
+		return nil, err
+	}
+	defer newMat.Close()
+
+	// Convert Mat into bytes
+	newImageBytes, err := u.encodeMat(newMat, contentType, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the logo, so the history links to it instead of embedding it
+	recorded := *params
+	var logoIDs []string
+	if len(params.Image) > 0 {
+		logoID, logoURL, err := u.storeLogo(ctx, params.Image)
+		if err != nil {
+			return nil, err
+		}
+		logoIDs = append(logoIDs, logoID)
+		recorded.Image = nil
+		recorded.ImageLink = logoURL
+	}
+
+	// Upload both images and create history
+	original := &encodedImage{
+		Bytes:       originalImageBytes,
+		ContentType: contentType,
+		Width:       originalMat.Cols(),
+		Height:      originalMat.Rows(),
+	}
+	watermarked := &encodedImage{
+		Bytes:        newImageBytes,
+		ContentType:  contentType,
+		Width:        newMat.Cols(),
+		Height:       newMat.Rows(),
+		WatermarkIDs: logoIDs,
+	}
+	response, err := u.save(ctx, "watermark_image", original, "original_", watermarked, "watermarked_", &recorded)
+	if err != nil {
+		// No history links to the logo, so it is removed right away
+		u.discard(ctx, logoIDs...)
+	}
+	return response, err
+}
+
+// Uploads the logo into storage, returning its public ID and URL
+func (u *ImageUseCase) storeLogo(ctx context.Context, logo []byte) (string, string, error) {
+	logoID := "watermark_" + uuid.New().String()
+	logoURL, err := u.Storage.Put(ctx, logoID, bytes.NewReader(logo), "image/png")
+	if err != nil {
+		u.Log.Warnf("Failed to upload watermark image : %+v", err)
+		return "", "", fiber.ErrInternalServerError
+	}
+
+	return logoID, logoURL, nil
+}
+
+// Stores the logos of the watermark operations, rewriting their parameters to link the stored logo
+// instead of embedding it. Returns the public IDs of the stored logos
+func (u *ImageUseCase) storeOperationLogos(ctx context.Context, operations []model.TransformOperation) ([]string, error) {
+	var logoIDs []string
+	for i, operation := range operations {
+		if operation.Type != "watermark" {
+			continue
+		}
+		// Parameters were already validated by the step, only the logo is read again
+		params := new(model.WatermarkParams)
+		fields := make(map[string]json.RawMessage)
+		err := json.Unmarshal(operation.Params, params)
+		if err == nil {
+			err = json.Unmarshal(operation.Params, &fields)
+		}
+		if err != nil {
+			u.Log.Warnf("Failed to decode watermark parameters : %+v", err)
+			u.discard(ctx, logoIDs...)
+			return nil, fiber.ErrInternalServerError
+		}
+		if len(params.Image) == 0 {
+			continue
+		}
+
+		logoID, logoURL, err := u.storeLogo(ctx, params.Image)
+		if err != nil {
+			u.discard(ctx, logoIDs...)
+			return nil, err
+		}
+		logoIDs = append(logoIDs, logoID)
+
+		delete(fields, "image")
+		fields["image_link"], _ = json.Marshal(logoURL)
+		operations[i].Params, _ = json.Marshal(fields)
+	}
+
+	return logoIDs, nil
+}
+
+func (u *ImageUseCase) watermarkStep(state *transformState, raw json.RawMessage) error {
+	params := new(model.WatermarkParams)
+	if err := u.decodeParams(raw, params); err != nil {
+		return err
+	}
+
+	newMat, err := u.watermarkMat(state.Mat, params)
+	if err != nil {
+		return err
+	}
+	state.replace(newMat)
+	return nil
+}
+
+// Overlays the logo or text onto a copy of the Mat, once placed by gravity or tiled over the whole image
+func (u *ImageUseCase) watermarkMat(src gocv.Mat, params *model.WatermarkParams) (gocv.Mat, error) {
+	// If 'opacity' is empty, set default as 0.5
+	opacity := params.Opacity
+	if opacity <= 0 {
+		opacity = 0.5
+	}
+
+	// If 'scale' is empty, set default as 0.2 of the image width
+	scale := params.Scale
+	if scale <= 0 {
+		scale = 0.2
+	}
+	width := max(int(math.Round(float64(src.Cols())*scale)), 1)
+	if params.Tiled {
+		width = max(width, watermarkMinTileWidth)
+	}
+
+	overlay, err := u.watermarkOverlay(params, width)
+	if err != nil {
+		return gocv.NewMat(), err
+	}
+	defer overlay.Close()
+
+	// Blending needs color channels, grayscale is expanded into BGR
+	dst := gocv.NewMat()
+	if src.Channels() == 1 {
+		gocv.CvtColor(src, &dst, gocv.ColorGrayToBGR)
+	} else {
+		src.CopyTo(&dst)
+	}
+
+	margin := params.MarginInPixels
+	if params.Tiled {
+		canvas, err := u.tileOverlay(overlay, dst.Cols(), dst.Rows(), margin)
+		if err != nil {
+			dst.Close()
+			return gocv.NewMat(), err
+		}
+		defer canvas.Close()
+		blendOverlay(&dst, canvas, image.Point{}, opacity)
+		return dst, nil
+	}
+
+	// If 'gravity' is empty, set default as south-east, placed within the margin
+	gravity := params.Gravity
+	if gravity == "" {
+		gravity = "south-east"
+	}
+	origin := gravityOrigin(gravity, dst.Cols()-2*margin, dst.Rows()-2*margin, overlay.Cols(), overlay.Rows())
+	blendOverlay(&dst, overlay, origin.Add(image.Point{X: margin, Y: margin}), opacity)
+
+	return dst, nil
+}
+
+// Repeats the overlay onto a transparent canvas of the given size, so the tiles are blended at once.
+// Tiles start from the margin and are spaced by it, partial tiles at the edges are clipped
+func (u *ImageUseCase) tileOverlay(overlay gocv.Mat, width, height, margin int) (gocv.Mat, error) {
+	stepX, stepY := overlay.Cols()+max(margin, 1), overlay.Rows()+max(margin, 1)
+	columns := (max(width-margin, 0) + stepX - 1) / stepX
+	rows := (max(height-margin, 0) + stepY - 1) / stepY
+	if columns*rows > watermarkMaxTiles {
+		u.Log.Warnf("Validation error : watermark would be tiled %d times", columns*rows)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("watermark would be tiled more than %d times, increase 'scale' or 'margin_in_pixels'", watermarkMaxTiles))
+	}
+
+	canvas := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), height, width, gocv.MatTypeCV8UC4)
+	bounds := image.Rect(0, 0, width, height)
+	for y := margin; y < height; y += stepY {
+		for x := margin; x < width; x += stepX {
+			origin := image.Point{X: x, Y: y}
+			box := image.Rectangle{Min: origin, Max: origin.Add(image.Point{X: overlay.Cols(), Y: overlay.Rows()})}.Intersect(bounds)
+			tile := overlay.Region(box.Sub(origin))
+			target := canvas.Region(box)
+			tile.CopyTo(&target)
+			tile.Close()
+			target.Close()
+		}
+	}
+
+	return canvas, nil
+}
+
+// Builds the BGRA watermark of the given width, from the logo or rendered from the text
+func (u *ImageUseCase) watermarkOverlay(params *model.WatermarkParams, width int) (gocv.Mat, error) {
+	if (len(params.Image) > 0) == (params.Text != "") {
+		u.Log.Warn("Validation error : watermark should be either an image or a text")
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "either 'watermark' image or 'text' is required")
+	}
+
+	if params.Text != "" {
+		return u.textOverlay(params, width)
+	}
+
+	// Validate if logo is in png
+	if http.DetectContentType(params.Image) != "image/png" {
+		u.Log.Warn("Validation error : watermark is not in png")
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "watermark should be in png")
+	}
+	logo, err := u.decodeMat(params.Image, false)
+	if err != nil {
+		return gocv.NewMat(), err
+	}
+	defer logo.Close()
+
+	// Logo is decoded unchanged, so its alpha channel is kept. Logo without one is considered fully opaque
+	bgra := gocv.NewMat()
+	defer bgra.Close()
+	switch logo.Channels() {
+	case 1:
+		gocv.CvtColor(logo, &bgra, gocv.ColorGrayToBGRA)
+	case 3:
+		gocv.CvtColor(logo, &bgra, gocv.ColorBGRToBGRA)
+	default:
+		logo.CopyTo(&bgra)
+	}
+
+	height := max(int(math.Round(float64(bgra.Rows())*float64(width)/float64(bgra.Cols()))), 1)
+	return resizeMatTo(bgra, width, height, &model.ResizeParams{}), nil
+}
+
+// Renders the text antialiased onto a transparent Mat, sized so the text spans the given width
+func (u *ImageUseCase) textOverlay(params *model.WatermarkParams, width int) (gocv.Mat, error) {
+	// If 'text_color' is empty, set default as white
+	textColor := params.TextColor
+	if textColor == "" {
+		textColor = "#ffffff"
+	}
+	foreground, err := helper.ParseHexColor(textColor)
+	if err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return gocv.NewMat(), fiber.NewError(fiber.StatusBadRequest, "'text_color' should be a hex color")
+	}
+	// Transparency of the text is given by opacity instead
+	foreground.A = 255
+
+	// Hershey fonts scale linearly, so the scale is derived from the size at scale 1
+	const font = gocv.FontHersheySimplex
+	unit := gocv.GetTextSize(params.Text, font, 1, 2)
+	fontScale := float64(width) / float64(max(unit.X, 1))
+	thickness := max(int(math.Round(fontScale*2)), 1)
+	size, baseline := gocv.GetTextSizeWithBaseline(params.Text, font, fontScale, thickness)
+
+	overlay := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0),
+		size.Y+baseline+thickness, size.X+thickness, gocv.MatTypeCV8UC4)
+	gocv.PutTextWithParams(&overlay, params.Text, image.Point{X: thickness / 2, Y: size.Y + thickness/2},
+		font, fontScale, foreground, thickness, gocv.LineAA, false)
+
+	return overlay, nil
+}
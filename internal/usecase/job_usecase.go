@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-image-api/internal/entity"
+	"go-image-api/internal/helper"
+	"go-image-api/internal/model"
+	"go-image-api/internal/model/converter"
+	"go-image-api/internal/repository"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+type JobUseCase struct {
+	ViperConfig    *viper.Viper
+	DB             *gorm.DB
+	Validate       *validator.Validate
+	Log            *logrus.Logger
+	JobRepository  *repository.JobRepository
+	ImageUseCase   *ImageUseCase
+	WebhookUseCase *WebhookUseCase
+}
+
+func NewJobUseCase(viperConfig *viper.Viper, db *gorm.DB, validate *validator.Validate, log *logrus.Logger,
+	jobRepository *repository.JobRepository, imageUseCase *ImageUseCase, webhookUseCase *WebhookUseCase) *JobUseCase {
+	return &JobUseCase{
+		ViperConfig:    viperConfig,
+		DB:             db,
+		Validate:       validate,
+		Log:            log,
+		JobRepository:  jobRepository,
+		ImageUseCase:   imageUseCase,
+		WebhookUseCase: webhookUseCase,
+	}
+}
+
+func (u *JobUseCase) Enqueue(ctx context.Context, request *model.EnqueueJobRequest) (*model.JobResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+	if request.Params != nil {
+		if err := u.Validate.Struct(request.Params); err != nil {
+			u.Log.Warnf("Validation error : %+v", err)
+			return nil, err
+		}
+	}
+
+	// Read uploaded image, it is kept in the job until processed
+	imageBytes, err := u.ImageUseCase.readImage(request.ImageFileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(request.Params)
+	if err != nil {
+		u.Log.Warnf("Failed to encode job payload : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// Commit job into DB
+	job := &entity.Job{
+		ID:          uuid.New().String(),
+		Type:        request.Type,
+		Status:      entity.JobStatusPending,
+		Payload:     payload,
+		Image:       imageBytes,
+		FileName:    request.ImageFileHeader.Filename,
+		CallbackURL: request.CallbackURL,
+		CreatedAt:   time.Now(),
+	}
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.JobRepository.Create(tx, job); err != nil {
+		u.Log.Warnf("Error adding job : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Error committing job : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.JobToResponse(job), nil
+}
+
+func (u *JobUseCase) Get(ctx context.Context, request *model.GetJobRequest) (*model.JobResponse, error) {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return nil, err
+	}
+
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// Find job, without loading the stored image
+	job := new(entity.Job)
+	if err := u.JobRepository.FindByID(tx.Omit("image"), job, request.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "job is not found")
+		}
+		u.Log.Warnf("Failed to find job : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Failed to commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.JobToResponse(job), nil
+}
+
+// Starts in-process workers which run queued jobs until the context is done
+func (u *JobUseCase) StartWorkers(ctx context.Context) {
+	// If worker count is not configured, set defaults to 2
+	workerCount := u.ViperConfig.GetInt("JOB_WORKER_COUNT")
+	if workerCount < 1 {
+		workerCount = 2
+	}
+
+	// If poll interval is not configured, set defaults to 1 second
+	pollInterval := time.Duration(u.ViperConfig.GetInt("JOB_POLL_INTERVAL_IN_MS")) * time.Millisecond
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	for w := 0; w < workerCount; w++ {
+		go u.work(ctx, pollInterval)
+	}
+}
+
+func (u *JobUseCase) work(ctx context.Context, pollInterval time.Duration) {
+	for {
+		// Keep draining the queue while there are jobs, only wait when it is empty
+		processed, err := u.processNext(ctx)
+		if err != nil {
+			u.Log.Warnf("Failed to process job : %+v", err)
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Claims the next queued job and runs it, returns false when there is nothing to run
+func (u *JobUseCase) processNext(ctx context.Context) (bool, error) {
+	// If stale timeout is not configured, set defaults to 10 minutes
+	staleTimeout := time.Duration(u.ViperConfig.GetInt("JOB_STALE_TIMEOUT_IN_SECONDS")) * time.Second
+	if staleTimeout <= 0 {
+		staleTimeout = 10 * time.Minute
+	}
+
+	// If max attempts is not configured, set defaults to 3
+	maxAttempts := u.ViperConfig.GetInt("JOB_MAX_ATTEMPTS")
+	if maxAttempts < 1 {
+		maxAttempts = 3
+	}
+
+	// Claim the job in a short transaction, so other workers see it as processing
+	job := new(entity.Job)
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.JobRepository.LockNext(tx, job, time.Now().Add(-staleTimeout)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// A stale job has lost its worker on every attempt so far, most likely by crashing it, so it is given up
+	if job.Status == entity.JobStatusProcessing && job.Attempts >= maxAttempts {
+		u.Log.Warnf("Job %s is given up after %d attempts", job.ID, job.Attempts)
+		err := fiber.NewError(fiber.StatusInternalServerError,
+			fmt.Sprintf("job was abandoned by its worker %d times", job.Attempts))
+		return true, u.finish(ctx, tx, job, nil, err)
+	}
+
+	startedAt := time.Now()
+	job.Status = entity.JobStatusProcessing
+	job.StartedAt = &startedAt
+	job.HeartbeatAt = &startedAt
+	job.Attempts++
+	if err := u.JobRepository.Save(tx, job); err != nil {
+		return false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	// Run the operation while the heartbeat is refreshed, so a job slower than the stale timeout is not picked again
+	stop := make(chan struct{})
+	go u.heartbeat(ctx, job.ID, staleTimeout/3, stop)
+	response, err := u.run(ctx, job)
+	close(stop)
+
+	tx = u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	return true, u.finish(ctx, tx, job, response, err)
+}
+
+// Refreshes the heartbeat of the running job every interval, until stop is closed
+func (u *JobUseCase) heartbeat(ctx context.Context, id string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := u.JobRepository.Heartbeat(u.DB.WithContext(ctx), id, time.Now()); err != nil {
+				u.Log.Warnf("Failed to refresh heartbeat of job %s : %+v", id, err)
+			}
+		}
+	}
+}
+
+// Records the outcome of the job within the transaction, then notifies the caller.
+// The stored image is released once the job is finished
+func (u *JobUseCase) finish(ctx context.Context, tx *gorm.DB, job *entity.Job, response *model.ImageResponse, err error) error {
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Image = nil
+	if err != nil {
+		job.Status = entity.JobStatusFailed
+		job.Error, _ = json.Marshal(helper.NewErrorResponse(err))
+	} else {
+		job.Status = entity.JobStatusSucceeded
+		job.Result, _ = json.Marshal(response)
+	}
+
+	if err := u.JobRepository.Save(tx, job); err != nil {
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	// Notify the caller with the finished job, which holds either the result or the error
+	if job.CallbackURL != "" {
+		if err := u.WebhookUseCase.Notify(ctx, &model.WebhookRequest{
+			CallbackURL: job.CallbackURL,
+			Payload:     converter.JobToResponse(job),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Runs the image operation of the job type with its stored parameters
+func (u *JobUseCase) run(ctx context.Context, job *entity.Job) (*model.ImageResponse, error) {
+	switch job.Type {
+	case "convert_png_jpeg":
+		params := &model.ConvertParams{TargetFormat: "jpeg"}
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.convertPNGToJPEG(ctx, params, job.Image)
+	case "convert_image":
+		params := new(model.ConvertParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.convertImage(ctx, params, job.Image)
+	case "resize_image":
+		params := new(model.ResizeParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.resizeImage(ctx, params, job.Image)
+	case "compress_image":
+		params := new(model.CompressParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.compressImage(ctx, params, job.Image)
+	case "crop_image":
+		params := new(model.CropParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.cropImage(ctx, params, job.Image)
+	case "rotate_image":
+		params := new(model.RotateParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.rotateImage(ctx, params, job.Image)
+	case "watermark_image":
+		params := new(model.WatermarkParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.watermarkImage(ctx, params, job.Image)
+	case "filter_image":
+		params := new(model.FilterParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.filterImage(ctx, params, job.Image)
+	case "enhance_image":
+		params := new(model.EnhanceParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.enhanceImage(ctx, params, job.Image)
+	case "denoise_image":
+		params := new(model.DenoiseParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.denoiseImage(ctx, params, job.Image)
+	case "transform_image":
+		params := new(model.TransformParams)
+		if err := u.ImageUseCase.decodeParams(job.Payload, params); err != nil {
+			return nil, err
+		}
+		return u.ImageUseCase.transformImage(ctx, params, job.Image)
+	}
+
+	u.Log.Warnf("Unknown job type : %s", job.Type)
+	return nil, fiber.ErrInternalServerError
+}
@@ -2,8 +2,8 @@ package usecase
 
 import (
 	"go-image-api/internal/repository"
+	"go-image-api/internal/storage"
 
-	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -11,12 +11,22 @@ import (
 )
 
 type UseCaseSetup struct {
-	ImageUseCase *ImageUseCase
+	ImageUseCase   *ImageUseCase
+	HistoryUseCase *HistoryUseCase
+	JobUseCase     *JobUseCase
+	WebhookUseCase *WebhookUseCase
 }
 
 func Setup(viperConfig *viper.Viper, db *gorm.DB, validate *validator.Validate, log *logrus.Logger,
-	cld *cloudinary.Cloudinary, repositorySetup *repository.RepositorySetup) *UseCaseSetup {
+	store storage.Storage, repositorySetup *repository.RepositorySetup) *UseCaseSetup {
+	imageUseCase := NewImageUseCase(viperConfig, db, validate, log, store, repositorySetup.HistoryRepository)
+	webhookUseCase := NewWebhookUseCase(viperConfig, db, validate, log,
+		repositorySetup.WebhookDeliveryRepository, repositorySetup.WebhookAttemptRepository)
+
 	return &UseCaseSetup{
-		ImageUseCase: NewImageUseCase(viperConfig, db, validate, log, cld, repositorySetup.HistoryRepository),
+		ImageUseCase:   imageUseCase,
+		HistoryUseCase: NewHistoryUseCase(db, validate, log, store, repositorySetup.HistoryRepository),
+		JobUseCase:     NewJobUseCase(viperConfig, db, validate, log, repositorySetup.JobRepository, imageUseCase, webhookUseCase),
+		WebhookUseCase: webhookUseCase,
 	}
 }
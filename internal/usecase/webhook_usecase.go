@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-image-api/internal/entity"
+	"go-image-api/internal/model"
+	"go-image-api/internal/repository"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+type WebhookUseCase struct {
+	ViperConfig               *viper.Viper
+	DB                        *gorm.DB
+	Validate                  *validator.Validate
+	Log                       *logrus.Logger
+	Client                    *http.Client
+	WebhookDeliveryRepository *repository.WebhookDeliveryRepository
+	WebhookAttemptRepository  *repository.WebhookAttemptRepository
+}
+
+func NewWebhookUseCase(viperConfig *viper.Viper, db *gorm.DB, validate *validator.Validate, log *logrus.Logger,
+	webhookDeliveryRepository *repository.WebhookDeliveryRepository,
+	webhookAttemptRepository *repository.WebhookAttemptRepository) *WebhookUseCase {
+	// If timeout is not configured, set defaults to 10 seconds
+	timeout := viperConfig.GetInt("WEBHOOK_TIMEOUT_IN_SECONDS")
+	if timeout < 1 {
+		timeout = 10
+	}
+
+	useCase := &WebhookUseCase{
+		ViperConfig:               viperConfig,
+		DB:                        db,
+		Validate:                  validate,
+		Log:                       log,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		WebhookAttemptRepository:  webhookAttemptRepository,
+	}
+	// Addresses are checked again when connecting, as the host may resolve differently than when it was validated
+	dialer := &net.Dialer{Timeout: time.Duration(timeout) * time.Second}
+	useCase.Client = &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return nil, err
+				}
+				if !useCase.allowedHost(host) {
+					checked := *dialer
+					checked.Control = func(network, address string, _ syscall.RawConn) error {
+						host, _, err := net.SplitHostPort(address)
+						if err != nil {
+							return err
+						}
+						if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+							return fmt.Errorf("address %s is not public", host)
+						}
+						return nil
+					}
+					return checked.DialContext(ctx, network, address)
+				}
+				return dialer.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout: time.Duration(timeout) * time.Second,
+		},
+	}
+
+	return useCase
+}
+
+// Validates callback url before the operation is run, an empty url means no callback
+func (u *WebhookUseCase) CheckCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+
+	// Deliveries signed with an empty key could be forged by anyone, so callbacks need the secret configured
+	if u.ViperConfig.GetString("WEBHOOK_SECRET") == "" {
+		u.Log.Warn("Validation error : 'callback_url' is given but WEBHOOK_SECRET is not configured")
+		return fiber.NewError(fiber.StatusBadRequest, "'callback_url' is not supported, webhook secret is not configured")
+	}
+
+	if err := u.Validate.Var(callbackURL, "http_url,max=2048"); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'callback_url' should be a valid http or https url")
+	}
+
+	// Internal addresses are refused unless the host is allowed, so callbacks can not reach the private network
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'callback_url' should be a valid http or https url")
+	}
+	if u.allowedHost(parsed.Hostname()) {
+		return nil
+	}
+	ips, err := net.LookupIP(parsed.Hostname())
+	if err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "'callback_url' host can not be resolved")
+	}
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			u.Log.Warnf("Validation error : 'callback_url' host %s resolves to %s", parsed.Hostname(), ip)
+			return fiber.NewError(fiber.StatusBadRequest,
+				"'callback_url' should not point to a loopback, private, or link-local address")
+		}
+	}
+
+	return nil
+}
+
+// Records the delivery, it is sent by the webhook workers and retried with exponential backoff
+func (u *WebhookUseCase) Notify(ctx context.Context, request *model.WebhookRequest) error {
+	// Validate request
+	if err := u.Validate.Struct(request); err != nil {
+		u.Log.Warnf("Validation error : %+v", err)
+		return err
+	}
+
+	payload, err := json.Marshal(request.Payload)
+	if err != nil {
+		u.Log.Warnf("Failed to encode webhook payload : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	// Commit delivery into DB, due right away
+	now := time.Now()
+	delivery := &entity.WebhookDelivery{
+		ID:            uuid.New().String(),
+		URL:           request.CallbackURL,
+		Payload:       payload,
+		Status:        entity.WebhookStatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.WebhookDeliveryRepository.Create(tx, delivery); err != nil {
+		u.Log.Warnf("Error adding webhook delivery : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if err := tx.Commit().Error; err != nil {
+		u.Log.Warnf("Error committing webhook delivery : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// Starts in-process workers which send due deliveries until the context is done.
+// Deliveries are polled from the DB, so the ones left pending by a restart are sent as well
+func (u *WebhookUseCase) StartWorkers(ctx context.Context) {
+	// If worker count is not configured, set defaults to 2
+	workerCount := u.ViperConfig.GetInt("WEBHOOK_WORKER_COUNT")
+	if workerCount < 1 {
+		workerCount = 2
+	}
+
+	// If poll interval is not configured, set defaults to 1 second
+	pollInterval := time.Duration(u.ViperConfig.GetInt("WEBHOOK_POLL_INTERVAL_IN_MS")) * time.Millisecond
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	for w := 0; w < workerCount; w++ {
+		go u.work(ctx, pollInterval)
+	}
+}
+
+func (u *WebhookUseCase) work(ctx context.Context, pollInterval time.Duration) {
+	for {
+		// Keep sending while deliveries are due, only wait when there is none
+		sent, err := u.deliverNext(ctx)
+		if err != nil {
+			u.Log.Warnf("Failed to deliver webhook : %+v", err)
+		}
+		if sent && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Claims the next due delivery and attempts it once, returns false when there is nothing due
+func (u *WebhookUseCase) deliverNext(ctx context.Context) (bool, error) {
+	// If max attempts is not configured, set defaults to 5
+	maxAttempts := u.ViperConfig.GetInt("WEBHOOK_MAX_ATTEMPTS")
+	if maxAttempts < 1 {
+		maxAttempts = 5
+	}
+
+	// If backoff is not configured, set defaults to 1 second, doubled after every failed attempt
+	backoff := time.Duration(u.ViperConfig.GetInt("WEBHOOK_BACKOFF_IN_MS")) * time.Millisecond
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	// Claim the delivery in a short transaction by postponing it past the request timeout,
+	// so it is attempted again only if this worker is gone before recording the attempt
+	delivery := new(entity.WebhookDelivery)
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.WebhookDeliveryRepository.LockNext(tx, delivery, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	delivery.NextAttemptAt = time.Now().Add(2 * u.Client.Timeout)
+	if err := u.WebhookDeliveryRepository.Update(tx, delivery); err != nil {
+		return false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	delivery.Attempts++
+	attempt := u.attempt(ctx, delivery, delivery.Attempts)
+	if err := u.record(ctx, attempt); err != nil {
+		u.Log.Warnf("Error adding webhook attempt : %+v", err)
+	}
+
+	now := time.Now()
+	switch {
+	case attempt.Error == "":
+		delivery.Status = entity.WebhookStatusDelivered
+		delivery.FinishedAt = &now
+	case delivery.Attempts >= maxAttempts:
+		u.Log.Warnf("Webhook delivery %s attempt %d failed, giving up : %s", delivery.ID, delivery.Attempts, attempt.Error)
+		delivery.Status = entity.WebhookStatusFailed
+		delivery.FinishedAt = &now
+	default:
+		u.Log.Warnf("Webhook delivery %s attempt %d failed : %s", delivery.ID, delivery.Attempts, attempt.Error)
+		delivery.NextAttemptAt = now.Add(backoff << (delivery.Attempts - 1))
+	}
+
+	// Commit delivery result into DB
+	tx = u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.WebhookDeliveryRepository.Update(tx, delivery); err != nil {
+		return true, err
+	}
+	return true, tx.Commit().Error
+}
+
+// Sends the payload once, any non 2xx response is considered as failed
+func (u *WebhookUseCase) attempt(ctx context.Context, delivery *entity.WebhookDelivery, number int) *entity.WebhookAttempt {
+	attempt := &entity.WebhookAttempt{
+		WebhookDeliveryID: delivery.ID,
+		Number:            number,
+		Timestamp:         time.Now(),
+	}
+
+	// Secret may have been removed since the delivery was recorded, it is never sent unsigned
+	secret := u.ViperConfig.GetString("WEBHOOK_SECRET")
+	if secret == "" {
+		attempt.Error = "WEBHOOK_SECRET is not configured"
+		return attempt
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-ID", delivery.ID)
+	request.Header.Set("X-Signature-256", "sha256="+sign(secret, delivery.Payload))
+
+	response, err := u.Client.Do(request)
+	attempt.DurationInMs = time.Since(attempt.Timestamp).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	attempt.StatusCode = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status : %s", response.Status)
+	}
+	return attempt
+}
+
+func (u *WebhookUseCase) record(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	tx := u.DB.WithContext(ctx).Begin()
+	defer tx.Rollback()
+	if err := u.WebhookAttemptRepository.Create(tx, attempt); err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Signs the payload with HMAC-SHA256 of the secret, receivers verify it against the raw body
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Reports whether the host is listed in WEBHOOK_ALLOWED_HOSTS, which may be reached even on internal addresses
+func (u *WebhookUseCase) allowedHost(host string) bool {
+	for _, allowed := range strings.Split(u.ViperConfig.GetString("WEBHOOK_ALLOWED_HOSTS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Shared address space of carrier-grade NAT, not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Reports whether the address is publicly routable, loopback, private, link-local, and multicast ones are not
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}